@@ -0,0 +1,49 @@
+// This is free and unencumbered software released into the public domain.
+
+package optparse
+
+import (
+	"fmt"
+	"strings"
+)
+
+// expand replaces the alias at the current position with its full
+// expansion. On a cycle, the alias is consumed and an error returned.
+func (p *Parser) expand() error {
+	arg := p.args[p.optind]
+	tokens, err := p.expansion(arg, []string{arg})
+	if err != nil {
+		p.optind++
+		return err
+	}
+	args := make([]string, 0, len(p.args)+len(tokens)-1)
+	args = append(args, p.args[:p.optind]...)
+	args = append(args, tokens...)
+	p.args = append(args, p.args[p.optind+1:]...)
+	p.expanded = p.optind + len(tokens)
+	return nil
+}
+
+// expansion recursively expands an alias, where chain lists the aliases
+// being expanded.
+func (p *Parser) expansion(alias string, chain []string) ([]string, error) {
+	var tokens []string
+	for i, token := range p.Aliases[alias] {
+		if _, ok := p.Aliases[token]; !ok || (i > 0 && !strings.HasPrefix(token, "-")) {
+			tokens = append(tokens, token)
+			continue
+		}
+		for _, name := range chain {
+			if name == token {
+				chain = append(chain, token)
+				return nil, fmt.Errorf("alias cycle: %s", strings.Join(chain, " -> "))
+			}
+		}
+		expanded, err := p.expansion(token, append(chain[:len(chain):len(chain)], token))
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, expanded...)
+	}
+	return tokens, nil
+}
@@ -0,0 +1,55 @@
+package optparse
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAliases(t *testing.T) {
+	aliases := map[string][]string{
+		"co":      {"checkout", "--quiet"},
+		"--fast":  {"-ab", "--quick"},
+		"--quick": {"-e"},
+		"-E":      {"-e", "-e"},
+		"ci":      {"co"},
+		"cycle":   {"-x1"},
+		"-x1":     {"-x2"},
+		"-x2":     {"-x1"},
+	}
+	table := []struct {
+		args []string
+		conf config
+		rest []string
+	}{
+		{[]string{"", "co", "-b"}, config{}, []string{"checkout", "--quiet", "-b"}},
+		{[]string{"", "-a", "ci"}, config{amend: true}, []string{"checkout", "--quiet"}},
+		{[]string{"", "--fast", "x"}, config{amend: true, brief: true, erase: 1}, []string{"x"}},
+		{[]string{"", "-E", "-E"}, config{erase: 4}, []string{}},
+		{[]string{"", "-d", "-E"}, config{delay: 0}, []string{}},
+		{[]string{"", "--", "co"}, config{}, []string{"co"}},
+		{[]string{"", "-x", "co"}, config{}, []string{"-x", "co"}},
+	}
+	for _, row := range table {
+		conf, rest, _ := parseWith(Config{Aliases: aliases}, row.args)
+		if conf != row.conf || !equal(rest, row.rest) {
+			t.Errorf("Parse(%q), got %v %q, want %v %q",
+				row.args, conf, rest, row.conf, row.rest)
+		}
+	}
+
+	args := []string{"", "co"}
+	parseWith(Config{Aliases: aliases}, args)
+	if args[1] != "co" {
+		t.Errorf("Parse(), modified args %q", args)
+	}
+
+	_, _, err := parseWith(Config{Aliases: aliases}, []string{"", "cycle"})
+	want := "alias cycle: cycle -> -x1 -> -x2 -> -x1"
+	if err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("Parse(), got %v, want %q", err, want)
+	}
+	_, _, err = parseWith(Config{Aliases: aliases, AllErrors: true}, []string{"", "-x1", "-x1"})
+	if err == nil {
+		t.Errorf("Parse(), got nil, want cycle errors")
+	}
+}
@@ -0,0 +1,46 @@
+// This is free and unencumbered software released into the public domain.
+
+package optparse
+
+// unbundle replaces the historical bundle of short options at the
+// current position with separate options, each followed by its
+// arguments if it requires any. If arguments run out, the bundle is
+// consumed and an error returned.
+func (p *Parser) unbundle() error {
+	bundle := p.args[p.optind]
+	next := p.optind + 1 // the next argument for an option
+	var tokens []string
+	for _, c := range bundle {
+		tokens = append(tokens, "-"+string(c))
+		option := p.findShort(c)
+		if option == nil {
+			continue
+		}
+		switch p.kind(option) {
+		case KindRequired:
+			if next == len(p.args) {
+				p.optind++
+				return Error{Option: *option, Err: ErrMissing}
+			}
+			tokens = append(tokens, p.args[next])
+			next++
+		case KindUntil:
+			end := next
+			for end < len(p.args) && p.args[end] != option.until() {
+				end++
+			}
+			if end == len(p.args) {
+				p.optind++
+				return Error{Option: *option, Err: ErrMissing}
+			}
+			tokens = append(tokens, p.args[next:end+1]...)
+			next = end + 1
+		}
+	}
+	args := make([]string, 0, len(p.args)-next+p.optind+len(tokens))
+	args = append(args, p.args[:p.optind]...)
+	args = append(args, tokens...)
+	p.args = append(args, p.args[next:]...)
+	p.expanded = p.optind + len(tokens)
+	return nil
+}
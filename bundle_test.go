@@ -0,0 +1,103 @@
+package optparse
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestBundled(t *testing.T) {
+	options := []Option{
+		{Short: 'c', Kind: KindNone},
+		{Short: 'x', Kind: KindNone},
+		{Short: 'v', Kind: KindNone},
+		{Short: 'f', Kind: KindRequired},
+		{Short: 'b', Kind: KindRequired},
+		{Short: 'z', Kind: KindOptional},
+		{Short: 'e', Kind: KindUntil},
+		{Short: 'p', Kind: KindRequired, Secret: true},
+	}
+	table := []struct {
+		args []string
+		want []string
+		rest []string
+		err  error
+	}{
+		{[]string{"tar", "xvf", "a.tar", "b"}, []string{"-x", "-v", "-fa.tar"}, []string{"b"}, nil},
+		{[]string{"tar", "-xvf", "a.tar", "b"}, []string{"-x", "-v", "-fa.tar"}, []string{"b"}, nil},
+		{[]string{"tar", "cbf", "20", "a.tar", "-z"}, []string{"-c", "-b20", "-fa.tar", "-z"}, nil, nil},
+		{[]string{"tar", "czf", "a.tar"}, []string{"-c", "-z", "-fa.tar"}, nil, nil},
+		{[]string{"tar", "xf"}, nil, nil, ErrMissing},
+		{[]string{"tar", "f"}, nil, nil, ErrMissing},
+		{[]string{"tar", "fx"}, nil, nil, ErrMissing},
+		{[]string{"tar", "xb", "1"}, []string{"-x", "-b1"}, nil, nil},
+		{[]string{"tar", "exf", "rm", "{}", ";", "a.tar"}, []string{"-e rm {} ;", "-x", "-fa.tar"}, nil, nil},
+		{[]string{"tar", "ex", "rm"}, nil, []string{"rm"}, ErrMissing},
+		{[]string{"tar", "pf", "a.tar"}, []string{"-phunter2", "-fa.tar"}, nil, nil},
+		{[]string{"tar", "xq"}, []string{"-x"}, []string{"-q"}, ErrInvalid},
+		{[]string{"tar"}, nil, nil, nil},
+	}
+	for _, row := range table {
+		fakeTerminal(t, "", true)
+		fakeSecrets(t, "hunter2")
+		results, rest, err := ParseWith(Config{Bundled: true}, options, row.args)
+		if !errors.Is(err, row.err) {
+			t.Errorf("ParseWith(%q), got error %v, want %v", row.args, err, row.err)
+		}
+		var got []string
+		for _, result := range results {
+			got = append(got, result.String())
+		}
+		if !equal(got, row.want) || !equal(rest, row.rest) {
+			t.Errorf("ParseWith(%q), got %q %q, want %q %q", row.args, got, rest, row.want, row.rest)
+		}
+	}
+
+	// the first argument only
+	_, rest, _ := ParseWith(Config{Bundled: true}, options, []string{"tar", "-x", "vf"})
+	if !equal(rest, []string{"vf"}) {
+		t.Errorf("ParseWith(), got %q, want [vf]", rest)
+	}
+}
+
+func TestDashless(t *testing.T) {
+	options := []Option{
+		{Short: 'a', Kind: KindNone},
+		{Short: 'u', Kind: KindNone},
+		{Short: 'x', Kind: KindNone},
+		{Short: 'o', Kind: KindRequired},
+	}
+	table := []struct {
+		args []string
+		want []string
+		rest []string
+	}{
+		{[]string{"ps", "aux"}, []string{"-a", "-u", "-x"}, nil},
+		{[]string{"ps", "-aux"}, []string{"-a", "-u", "-x"}, nil},
+		{[]string{"ps", "opid", "x"}, []string{"-opid"}, []string{"x"}},
+		{[]string{"ps", "o", "pid", "ax"}, []string{"-opid", "-a", "-x"}, nil},
+		{[]string{"ps", "-x", "ax"}, []string{"-x"}, []string{"ax"}},
+		{[]string{"ps", "-x", "-a", "ax"}, []string{"-x", "-a", "-a", "-x"}, nil},
+		{[]string{"ps", "a", "u", "x"}, []string{"-a"}, []string{"u", "x"}},
+	}
+	for _, row := range table {
+		config := Config{Dashless: []int{1, 3}}
+		results, rest, err := ParseWith(config, options, row.args)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, result := range results {
+			got = append(got, result.String())
+		}
+		if !equal(got, row.want) || !equal(rest, row.rest) {
+			t.Errorf("ParseWith(%q), got %q %q, want %q %q", row.args, got, rest, row.want, row.rest)
+		}
+	}
+
+	// unrecognized options are skipped one at a time
+	config := Config{Dashless: []int{1}, Lenient: true}
+	results, _, err := ParseWith(config, options, []string{"ps", "qaz"})
+	if err != nil || len(results) != 1 || results[0].Short != 'a' {
+		t.Errorf("ParseWith(), got %v %v, want [-a]", results, err)
+	}
+}
@@ -0,0 +1,303 @@
+// This is free and unencumbered software released into the public domain.
+
+package optparse
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Errors wrapped by CommandError, for use with errors.Is.
+var (
+	// ErrCommand is used when a command name is not recognized.
+	ErrCommand = errors.New("unknown command")
+	// ErrAmbiguous is used when an abbreviated command name matches
+	// more than one command.
+	ErrAmbiguous = errors.New("ambiguous command")
+)
+
+// Command is a node in a tree of subcommands, as in "git commit". Each
+// command has its own options, parsed up to the first operand, which
+// then names one of its subcommands.
+type Command struct {
+	Name     string
+	Aliases  []string
+	Options  []Option
+	Commands []*Command
+
+	// Operands, if not nil, declares the operands of a command without
+	// subcommands, which Parse matches as with ParseOperands.
+	Operands []Operand
+
+	// Abbreviate accepts any unambiguous prefix of a subcommand's name
+	// or alias.
+	Abbreviate bool
+
+	// Help is a short description of the command shown by its parent's
+	// Usage, and Group names the section listing it there, such as
+	// "Basic Commands".
+	Help  string
+	Group string
+
+	// Config adjusts the parsing of this command's options.
+	Config Config
+
+	// Default selects this command when its parent's first operand
+	// names no subcommand, or when there are no operands, which are
+	// then left for this command.
+	Default bool
+
+	// Before and After, if not nil, are called by Execute with the
+	// command's parsed options, whether it or a descendant runs, for
+	// uniform setup and teardown. Run performs the command itself.
+	Before func(results Results) error
+	After  func(results Results) error
+	Run    func(inv Invocation) error
+}
+
+// Invocation is the result of parsing a command line against a tree of
+// commands. Commands is the path of selected commands starting with
+// the root, and Results holds each one's parsed options. Operands holds
+// the Rest matched to the last command's declared Operands.
+type Invocation struct {
+	Commands []*Command
+	Results  []Results
+	Rest     []string
+	Operands Positionals
+}
+
+// CommandError reports an unusable command name. Err is ErrCommand or
+// ErrAmbiguous, and Index is the position of Name in args. Suggestions
+// lists similarly-named commands or, if ambiguous, the candidates.
+// Implements error.
+type CommandError struct {
+	Name        string
+	Err         error
+	Index       int
+	Suggestions []string
+}
+
+func (e CommandError) Error() string {
+	if e.Err == ErrAmbiguous {
+		return fmt.Sprintf("%s: %s (%s)", e.Err, e.Name,
+			strings.Join(e.Suggestions, ", "))
+	}
+	return fmt.Sprintf("%s: %s", e.Err, e.Name)
+}
+
+// Unwrap returns Err.
+func (e CommandError) Unwrap() error {
+	return e.Err
+}
+
+// Parse parses args, skipping args[0], in two or more phases: first the
+// command's own options, then, if it has subcommands, those of the
+// subcommand named by the first operand, and so on. The terminator ends
+// both option parsing and command selection, leaving the remaining
+// arguments as operands of the current command. Persistent options are
+// also accepted after the names of descendant commands, with their
+// results placed at the level of the defining command. Indices in
+// Results and errors refer to positions in args. On error, the
+// Invocation holds the commands and results up to the error.
+func (c *Command) Parse(args []string) (Invocation, error) {
+	var inv Invocation
+	var errs []error
+	var inherited []Option
+	p := NewParser(nil, args)
+	for cmd := c; ; {
+		p.Config = cmd.Config
+		p.setOptions(append(cmd.Options[:len(cmd.Options):len(cmd.Options)], inherited...))
+		inv.Commands = append(inv.Commands, cmd)
+		inv.Results = append(inv.Results, nil)
+		results, skipped, err := p.collect(nil)
+		inv.place(results)
+		inv.Rest = p.Rest()
+		errs = append(errs, skipped...)
+		if err != nil {
+			return inv, err
+		}
+		if p.terminated || len(cmd.Commands) == 0 {
+			break
+		}
+
+		next, named, err := cmd.route(inv.Rest)
+		if err != nil {
+			e := err.(CommandError)
+			e.Index = p.Index()
+			return inv, e
+		}
+		if next == nil {
+			break
+		}
+		if named {
+			p.optind++
+		}
+		var persistent []Option
+		for _, option := range cmd.Options {
+			if option.Persistent {
+				persistent = append(persistent, option)
+			}
+		}
+		inherited = append(persistent, inherited...) // nearest first
+		cmd = next
+	}
+
+	for i, cmd := range inv.Commands {
+		p.Config = cmd.Config
+		inv.Results[i] = p.ask(cmd.Options, inv.Results[i], 0)
+		errs = append(errs, p.missing(cmd.Options, inv.Results[i])...)
+	}
+	if last := inv.Commands[len(inv.Commands)-1]; last.Operands != nil {
+		offset := len(args) - len(inv.Rest)
+		positionals, err := ParseOperands(last.Operands, inv.Rest)
+		for i := range positionals {
+			positionals[i].Index += offset
+		}
+		inv.Operands = positionals
+		if e, ok := err.(OperandError); ok {
+			e.Index += offset
+			errs = append(errs, e)
+		}
+	}
+	return inv, errors.Join(errs...)
+}
+
+// Execute parses args as with Parse and runs the selected command:
+// each Before hook from the root down, the Run of the last command, and
+// then in reverse the After hooks of those whose Before hooks
+// succeeded. After hooks run even if Run fails, and all errors are
+// joined. Parsing errors are returned without running anything.
+func (c *Command) Execute(args []string) error {
+	inv, err := c.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	n := 0
+	for ; n < len(inv.Commands); n++ {
+		cmd := inv.Commands[n]
+		if cmd.Before == nil {
+			continue
+		}
+		if err := cmd.Before(inv.Results[n]); err != nil {
+			errs = append(errs, err)
+			break
+		}
+	}
+	if n == len(inv.Commands) {
+		if run := inv.Commands[n-1].Run; run != nil {
+			errs = append(errs, run(inv))
+		}
+	}
+	for i := n - 1; i >= 0; i-- {
+		if after := inv.Commands[i].After; after != nil {
+			errs = append(errs, after(inv.Results[i]))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// place appends results parsed for the last command to the Results of
+// the commands defining them.
+func (inv *Invocation) place(results Results) {
+	last := len(inv.Commands) - 1
+	for _, result := range results {
+		level := last
+		if !inv.Commands[last].defines(result, false) {
+			for i := last - 1; i >= 0; i-- {
+				if inv.Commands[i].defines(result, true) {
+					level = i
+					break
+				}
+			}
+		}
+		inv.Results[level] = append(inv.Results[level], result)
+	}
+}
+
+// defines reports whether one of the command's options, or only its
+// persistent options, produced the result.
+func (c *Command) defines(result Result, persistent bool) bool {
+	for _, option := range c.Options {
+		if (option.Persistent || !persistent) && option.defines(result) {
+			return true
+		}
+	}
+	return false
+}
+
+// route selects the subcommand for the remaining arguments, reporting
+// whether it was named by the first of them rather than a default.
+func (c *Command) route(rest []string) (*Command, bool, error) {
+	if len(rest) > 0 {
+		subs := c.find(rest[0])
+		switch {
+		case len(subs) == 1:
+			return subs[0], true, nil
+		case len(subs) > 1:
+			var names []string
+			for _, sub := range subs {
+				names = append(names, sub.Name)
+			}
+			return nil, false, CommandError{
+				Name:        rest[0],
+				Err:         ErrAmbiguous,
+				Suggestions: names,
+			}
+		}
+	}
+
+	for _, sub := range c.Commands {
+		if sub.Default {
+			return sub, false, nil
+		}
+	}
+	if len(rest) == 0 {
+		return nil, false, nil
+	}
+
+	var names []string
+	for _, sub := range c.Commands {
+		names = append(names, sub.Name)
+		names = append(names, sub.Aliases...)
+	}
+	return nil, false, CommandError{
+		Name:        rest[0],
+		Err:         ErrCommand,
+		Suggestions: Suggest(rest[0], names),
+	}
+}
+
+// find returns the subcommand named or aliased by name, or if
+// abbreviation is allowed, every subcommand with a name or alias
+// beginning with it.
+func (c *Command) find(name string) []*Command {
+	for _, sub := range c.Commands {
+		if sub.matches(name, false) {
+			return []*Command{sub}
+		}
+	}
+	if !c.Abbreviate || name == "" {
+		return nil
+	}
+	var subs []*Command
+	for _, sub := range c.Commands {
+		if sub.matches(name, true) {
+			subs = append(subs, sub)
+		}
+	}
+	return subs
+}
+
+// matches reports whether name is the command's name or an alias or,
+// optionally, a prefix of one.
+func (c *Command) matches(name string, prefix bool) bool {
+	for _, n := range append([]string{c.Name}, c.Aliases...) {
+		if n == name || prefix && strings.HasPrefix(n, name) {
+			return true
+		}
+	}
+	return false
+}
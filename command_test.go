@@ -0,0 +1,309 @@
+package optparse
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+var commands = &Command{
+	Name: "git",
+	Options: []Option{
+		{Long: "verbose", Short: 'v', Kind: KindNone, Persistent: true},
+		{Long: "work-tree", Short: 'C', Kind: KindRequired},
+	},
+	Commands: []*Command{
+		{
+			Name:    "commit",
+			Options: []Option{{Long: "amend", Short: 'a', Kind: KindNone}},
+		},
+		{
+			Name: "remote",
+			Commands: []*Command{
+				{Name: "add", Options: []Option{{Long: "fetch", Short: 'f', Kind: KindNone}}},
+			},
+		},
+		{Name: "status"},
+	},
+}
+
+func TestCommand(t *testing.T) {
+	table := []struct {
+		args []string
+		path []string
+		opts [][]string
+		rest []string
+	}{
+		{
+			[]string{"git", "-v", "commit", "-a", "x"},
+			[]string{"git", "commit"},
+			[][]string{{"verbose"}, {"amend"}},
+			[]string{"x"},
+		},
+		{
+			[]string{"git", "-C", "dir", "remote", "add", "-f", "--", "-x"},
+			[]string{"git", "remote", "add"},
+			[][]string{{"work-tree"}, nil, {"fetch"}},
+			[]string{"-x"},
+		},
+		{
+			[]string{"git", "-v"},
+			[]string{"git"},
+			[][]string{{"verbose"}},
+			[]string{},
+		},
+		{
+			[]string{"git", "--", "commit"},
+			[]string{"git"},
+			[][]string{nil},
+			[]string{"commit"},
+		},
+		{
+			[]string{"git", "status", "commit"},
+			[]string{"git", "status"},
+			[][]string{nil, nil},
+			[]string{"commit"},
+		},
+	}
+	for _, row := range table {
+		inv, err := commands.Parse(row.args)
+		if err != nil {
+			t.Errorf("Parse(%q), got %v", row.args, err)
+			continue
+		}
+		var path []string
+		var opts [][]string
+		for i, cmd := range inv.Commands {
+			path = append(path, cmd.Name)
+			var names []string
+			for _, result := range inv.Results[i] {
+				names = append(names, result.Long)
+			}
+			opts = append(opts, names)
+		}
+		if !reflect.DeepEqual(path, row.path) || !reflect.DeepEqual(opts, row.opts) ||
+			!equal(inv.Rest, row.rest) {
+			t.Errorf("Parse(%q), got %q %q %q, want %q %q %q", row.args,
+				path, opts, inv.Rest, row.path, row.opts, row.rest)
+		}
+	}
+}
+
+func TestCommandError(t *testing.T) {
+	inv, err := commands.Parse([]string{"git", "-v", "comit"})
+	want := CommandError{Name: "comit", Err: ErrCommand, Index: 2, Suggestions: []string{"commit"}}
+	if !reflect.DeepEqual(err, want) || !errors.Is(err, ErrCommand) {
+		t.Errorf("Parse(), got %#v, want %#v", err, want)
+	}
+	if len(inv.Commands) != 1 || !equal(inv.Rest, []string{"comit"}) {
+		t.Errorf("Parse(), got %d commands, rest %q", len(inv.Commands), inv.Rest)
+	}
+	if err.Error() != "unknown command: comit" {
+		t.Errorf("Error(), got %q", err)
+	}
+
+	// error positions are relative to the full command line
+	_, err = commands.Parse([]string{"git", "commit", "-a", "-x"})
+	var e Error
+	if !errors.As(err, &e) || e.Index != 3 || e.Arg != "-x" {
+		t.Errorf("Parse(), got %#v", err)
+	}
+}
+
+func TestPersistent(t *testing.T) {
+	root := &Command{
+		Options: []Option{
+			{Long: "verbose", Short: 'v', Kind: KindNone, Persistent: true},
+			{Long: "level", Short: 'l', Kind: KindRequired, Persistent: true},
+			{Long: "token", Kind: KindRequired, Persistent: true, Required: true},
+			{Long: "local", Kind: KindNone},
+		},
+		Commands: []*Command{{
+			Name: "remote",
+			Options: []Option{
+				{Long: "level", Short: 'l', Kind: KindNone}, // shadows
+			},
+			Commands: []*Command{{Name: "add"}},
+		}},
+	}
+
+	args := []string{"", "-v", "remote", "-l", "add", "-vl3", "--token=x"}
+	inv, err := root.Parse(args)
+	if err != nil {
+		t.Fatalf("Parse(), got %v", err)
+	}
+	var got [][]string
+	for _, results := range inv.Results {
+		var level []string
+		for _, result := range results {
+			level = append(level, fmt.Sprint(result.Long, "@", result.Index))
+		}
+		got = append(got, level)
+	}
+	// -l at the add level is the root's, as remote's does not persist
+	want := [][]string{{"verbose@1", "verbose@5", "level@5", "token@6"}, {"level@3"}, nil}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Parse(), got %q, want %q", got, want)
+	}
+
+	_, err = root.Parse([]string{"", "remote", "--local"})
+	if !errors.Is(err, ErrInvalid) {
+		t.Errorf("Parse(), got %v, want %v", err, ErrInvalid)
+	}
+
+	_, err = root.Parse([]string{"", "remote", "add"})
+	if !errors.Is(err, ErrRequired) {
+		t.Errorf("Parse(), got %v, want %v", err, ErrRequired)
+	}
+}
+
+func TestDefault(t *testing.T) {
+	root := &Command{
+		Options: []Option{{Long: "verbose", Kind: KindNone, Persistent: true}},
+		Commands: []*Command{
+			{Name: "init"},
+			{Name: "run", Default: true, Options: []Option{{Long: "dry", Kind: KindNone}}},
+		},
+	}
+	table := []struct {
+		args []string
+		path string
+		rest []string
+	}{
+		{[]string{"", "--verbose", "file.txt"}, "run", []string{"file.txt"}},
+		{[]string{"", "--verbose"}, "run", []string{}},
+		{[]string{"", "run", "--dry", "init"}, "run", []string{"init"}},
+		{[]string{"", "init", "run"}, "init", []string{"run"}},
+		{[]string{"", "--", "init"}, "", []string{"init"}},
+	}
+	for _, row := range table {
+		inv, err := root.Parse(row.args)
+		if err != nil {
+			t.Errorf("Parse(%q), got %v", row.args, err)
+			continue
+		}
+		if got := inv.Commands[len(inv.Commands)-1].Name; got != row.path || !equal(inv.Rest, row.rest) {
+			t.Errorf("Parse(%q), got %q %q, want %q %q", row.args, got, inv.Rest, row.path, row.rest)
+		}
+	}
+}
+
+func TestCommandAliases(t *testing.T) {
+	root := &Command{
+		Abbreviate: true,
+		Commands: []*Command{
+			{Name: "remove", Aliases: []string{"rm", "delete"}},
+			{Name: "rename", Aliases: []string{"mv"}},
+			{Name: "re"},
+			{Name: "list"},
+		},
+	}
+	table := []struct {
+		arg  string
+		want string
+		err  error
+	}{
+		{"rm", "remove", nil},
+		{"mv", "rename", nil},
+		{"del", "remove", nil},
+		{"l", "list", nil},
+		{"re", "re", nil},
+		{"ren", "rename", nil},
+		{"rem", "remove", nil},
+		{"r", "", ErrAmbiguous},
+		{"x", "", ErrCommand},
+	}
+	for _, row := range table {
+		inv, err := root.Parse([]string{"", row.arg})
+		if !errors.Is(err, row.err) {
+			t.Errorf("Parse(%q), got %v, want %v", row.arg, err, row.err)
+			continue
+		}
+		if err == nil && inv.Commands[1].Name != row.want {
+			t.Errorf("Parse(%q), got %q, want %q", row.arg, inv.Commands[1].Name, row.want)
+		}
+	}
+
+	_, err := root.Parse([]string{"", "r"})
+	want := CommandError{
+		Name:        "r",
+		Err:         ErrAmbiguous,
+		Index:       1,
+		Suggestions: []string{"remove", "rename", "re"},
+	}
+	if !reflect.DeepEqual(err, want) {
+		t.Errorf("Parse(), got %#v, want %#v", err, want)
+	}
+	if err.Error() != "ambiguous command: r (remove, rename, re)" {
+		t.Errorf("Error(), got %q", err)
+	}
+
+	root.Abbreviate = false
+	if _, err := root.Parse([]string{"", "ren"}); !errors.Is(err, ErrCommand) {
+		t.Errorf("Parse(), got %v, want %v", err, ErrCommand)
+	}
+}
+
+func TestExecute(t *testing.T) {
+	var log []string
+	hook := func(name string, err error) func(Results) error {
+		return func(results Results) error {
+			log = append(log, fmt.Sprint(name, len(results)))
+			return err
+		}
+	}
+	errFail := errors.New("fail")
+	root := &Command{
+		Options: []Option{{Long: "verbose", Short: 'v', Kind: KindNone, Persistent: true}},
+		Before:  hook("before-root", nil),
+		After:   hook("after-root", nil),
+		Commands: []*Command{
+			{
+				Name:   "run",
+				Before: hook("before-run", nil),
+				After:  hook("after-run", nil),
+				Run: func(inv Invocation) error {
+					log = append(log, fmt.Sprint("run", inv.Rest))
+					return errFail
+				},
+			},
+			{
+				Name:   "broken",
+				Before: hook("before-broken", errFail),
+				After:  hook("after-broken", nil),
+				Run: func(Invocation) error {
+					log = append(log, "unreachable")
+					return nil
+				},
+			},
+		},
+	}
+
+	table := []struct {
+		args []string
+		log  []string
+	}{
+		{
+			[]string{"", "-v", "run", "-v", "x"},
+			[]string{"before-root2", "before-run0", "run[x]", "after-run0", "after-root2"},
+		},
+		{
+			[]string{"", "broken"},
+			[]string{"before-root0", "before-broken0", "after-root0"},
+		},
+		{
+			[]string{"", "-x", "run"},
+			nil,
+		},
+	}
+	for _, row := range table {
+		log = nil
+		if err := root.Execute(row.args); err == nil {
+			t.Errorf("Execute(%q), got nil error", row.args)
+		}
+		if !reflect.DeepEqual(log, row.log) {
+			t.Errorf("Execute(%q), got %q, want %q", row.args, log, row.log)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+// This is free and unencumbered software released into the public domain.
+
+// Package complete generates shell completion scripts from optparse
+// option tables and command trees.
+package complete
+
+import (
+	"strings"
+
+	"nullprogram.com/x/optparse"
+)
+
+// node is a command along with its path and the persistent options it
+// inherits from its ancestors.
+type node struct {
+	cmd     *optparse.Command
+	path    []string
+	chain   []*optparse.Command // commands along path
+	options []optparse.Option   // own options followed by inherited
+}
+
+// walk calls f for cmd and each of its descendants, depth first.
+func walk(cmd *optparse.Command, f func(node)) {
+	var visit func(*optparse.Command, node, []optparse.Option)
+	visit = func(cmd *optparse.Command, parent node, inherited []optparse.Option) {
+		n := node{
+			cmd:     cmd,
+			path:    append(parent.path[:len(parent.path):len(parent.path)], cmd.Name),
+			chain:   append(parent.chain[:len(parent.chain):len(parent.chain)], cmd),
+			options: append(cmd.Options[:len(cmd.Options):len(cmd.Options)], inherited...),
+		}
+		f(n)
+
+		var persistent []optparse.Option
+		for _, option := range cmd.Options {
+			if option.Persistent {
+				persistent = append(persistent, option)
+			}
+		}
+		inherited = append(persistent, inherited...)
+		for _, sub := range cmd.Commands {
+			visit(sub, n, inherited)
+		}
+	}
+	visit(cmd, node{}, nil)
+}
+
+// names returns the option's names with dashes, short first.
+func names(option optparse.Option) []string {
+	var names []string
+	if option.Short != 0 {
+		names = append(names, "-"+string(option.Short))
+	}
+	if option.Long != "" {
+		names = append(names, "--"+option.Long)
+	}
+	return names
+}
+
+// negation returns the option forming a --name/--no-name pair with the
+// given option, or nil.
+func negation(options []optparse.Option, option optparse.Option) *optparse.Option {
+	if option.Long == "" {
+		return nil
+	}
+	for i, other := range options {
+		if other.Long == "no-"+option.Long || "no-"+other.Long == option.Long {
+			return &options[i]
+		}
+	}
+	return nil
+}
+
+// identifier converts a command path to a shell function name.
+func identifier(path []string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return '_'
+	}, "_"+strings.Join(path, "_"))
+}
+
+// quote returns s as a single-quoted shell word.
+func quote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
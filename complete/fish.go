@@ -0,0 +1,88 @@
+// This is free and unencumbered software released into the public domain.
+
+package complete
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"nullprogram.com/x/optparse"
+)
+
+// Fish writes fish completions for the command as complete statements.
+// Options requiring an argument are marked with -r. Arguments complete
+// as the option's Choices, if any, or else as files (-F) or directories
+// according to CompleteAs. Subcommands are offered until one is given,
+// after which its options are completed, along with the persistent
+// options of its ancestors. Install the script as NAME.fish in a fish
+// completions directory.
+func Fish(w io.Writer, cmd *optparse.Command) error {
+	b := bufio.NewWriter(w)
+	walk(cmd, func(n node) {
+		var seen []string
+		var subs []string
+		for _, c := range n.chain[1:] {
+			seen = append(seen, "__fish_seen_subcommand_from "+
+				strings.Join(append([]string{c.Name}, c.Aliases...), " "))
+		}
+		for _, sub := range n.cmd.Commands {
+			subs = append(subs, sub.Name)
+			subs = append(subs, sub.Aliases...)
+		}
+		here := seen
+		if subs != nil {
+			here = append(seen[:len(seen):len(seen)],
+				"not __fish_seen_subcommand_from "+strings.Join(subs, " "))
+		}
+
+		for _, option := range n.cmd.Options {
+			if option.Prefix {
+				continue // open-ended names cannot be listed
+			}
+			cond := here
+			if option.Persistent {
+				cond = seen
+			}
+			fmt.Fprintf(b, "complete -c %s", quote(cmd.Name))
+			if cond != nil {
+				fmt.Fprintf(b, " -n %s", quote(strings.Join(cond, "; and ")))
+			}
+			if option.Short != 0 {
+				fmt.Fprintf(b, " -s %s", quote(string(option.Short)))
+			}
+			if option.Long != "" {
+				fmt.Fprintf(b, " -l %s", quote(option.Long))
+			}
+			if option.Kind == optparse.KindRequired {
+				fmt.Fprint(b, " -r")
+			}
+			switch {
+			case option.Kind == optparse.KindNone:
+			case option.Choices != nil:
+				fmt.Fprintf(b, " -f -a %s", quote(strings.Join(option.Choices, " ")))
+			case option.CompleteAs == optparse.HintFiles:
+				fmt.Fprint(b, " -F")
+			case option.CompleteAs == optparse.HintDirs:
+				fmt.Fprint(b, " -f -a '(__fish_complete_directories)'")
+			}
+			if option.Help != "" {
+				fmt.Fprintf(b, " -d %s", quote(option.Help))
+			}
+			fmt.Fprintln(b)
+		}
+
+		for _, sub := range n.cmd.Commands {
+			for _, name := range append([]string{sub.Name}, sub.Aliases...) {
+				fmt.Fprintf(b, "complete -c %s -f -n %s -a %s", quote(cmd.Name),
+					quote(strings.Join(here, "; and ")), quote(name))
+				if sub.Help != "" {
+					fmt.Fprintf(b, " -d %s", quote(sub.Help))
+				}
+				fmt.Fprintln(b)
+			}
+		}
+	})
+	return b.Flush()
+}
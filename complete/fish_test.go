@@ -0,0 +1,46 @@
+package complete
+
+import (
+	"bytes"
+	"testing"
+
+	"nullprogram.com/x/optparse"
+)
+
+func TestFish(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Fish(&buf, command); err != nil {
+		t.Fatal(err)
+	}
+	want := `complete -c 'vcs' -s 'v' -l 'verbose' -d 'explain [more]'
+complete -c 'vcs' -n 'not __fish_seen_subcommand_from commit ci status' -l 'color' -d 'colorize'
+complete -c 'vcs' -n 'not __fish_seen_subcommand_from commit ci status' -l 'no-color'
+complete -c 'vcs' -f -n 'not __fish_seen_subcommand_from commit ci status' -a 'commit' -d 'record changes'
+complete -c 'vcs' -f -n 'not __fish_seen_subcommand_from commit ci status' -a 'ci' -d 'record changes'
+complete -c 'vcs' -f -n 'not __fish_seen_subcommand_from commit ci status' -a 'status'
+complete -c 'vcs' -n '__fish_seen_subcommand_from commit ci' -s 'm' -l 'message' -r -d 'use '\''this'\'' message'
+`
+	if got := buf.String(); got != want {
+		t.Errorf("Fish(), got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestFishHints(t *testing.T) {
+	root := &optparse.Command{
+		Name: "tar",
+		Options: []optparse.Option{
+			{Long: "file", Short: 'f', Kind: optparse.KindRequired, CompleteAs: optparse.HintFiles},
+			{Short: 'C', Kind: optparse.KindRequired, CompleteAs: optparse.HintDirs},
+			{Long: "format", Kind: optparse.KindRequired, Choices: []string{"gnu", "ustar"}},
+		},
+	}
+	var buf bytes.Buffer
+	Fish(&buf, root)
+	want := `complete -c 'tar' -s 'f' -l 'file' -r -F
+complete -c 'tar' -s 'C' -r -f -a '(__fish_complete_directories)'
+complete -c 'tar' -l 'format' -r -f -a 'gnu ustar'
+`
+	if got := buf.String(); got != want {
+		t.Errorf("Fish(), got:\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -0,0 +1,287 @@
+// This is free and unencumbered software released into the public domain.
+
+package complete
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"nullprogram.com/x/optparse"
+)
+
+// Hooks for testing Handle.
+var (
+	stdout io.Writer = os.Stdout
+	getenv           = os.Getenv
+)
+
+// Handle answers a completion request, if args is one, by printing
+// candidates to standard output, one per line, and reports whether it
+// did so, in which case the program should exit. It recognizes two
+// protocols. With a first argument of "__complete", the remaining
+// arguments are the words following the program name, the last being
+// the word to complete. Otherwise the COMP_LINE and COMP_POINT
+// environment variables, as set by bash for "complete -C prog prog",
+// give the command line and cursor position.
+func Handle(cmd *optparse.Command, args []string) bool {
+	var words []string
+	switch line := getenv("COMP_LINE"); {
+	case len(args) > 1 && args[1] == "__complete":
+		words = args[2:]
+	case line != "":
+		point, err := strconv.Atoi(getenv("COMP_POINT"))
+		if err != nil || point > len(line) {
+			point = len(line)
+		}
+		words = Line(line[:point])
+		if len(words) > 0 {
+			words = words[1:] // program name
+		}
+	default:
+		return false
+	}
+	for _, candidate := range Candidates(cmd, words) {
+		fmt.Fprintln(stdout, candidate)
+	}
+	return true
+}
+
+// Line splits a command line ending at the cursor into words, following
+// the quoting rules of optparse.SplitLine, the last being the word under
+// completion, which is empty after a space. That word may be within
+// quotes or end with a backslash.
+func Line(line string) []string {
+	// Append a sentinel to the final word, closing any quote, then
+	// remove it, leaving an empty word after a space.
+	for _, suffix := range []string{"x", "x'", `x"`} {
+		words, err := optparse.SplitLine(line + suffix)
+		if err == nil {
+			last := words[len(words)-1]
+			words[len(words)-1] = last[:strings.LastIndexByte(last, 'x')]
+			return words
+		}
+	}
+	return nil
+}
+
+// Candidates returns the completions of the last of words, which follow
+// the program name, computed from the live command tree: option names,
+// subcommand names, and aliases, and for option arguments, the option's
+// Choices and those supplied by its Complete and CompleteAs.
+func Candidates(cmd *optparse.Command, words []string) []string {
+	if len(words) == 0 {
+		words = []string{""}
+	}
+	partial := words[len(words)-1]
+	s := state{cmd: cmd, options: cmd.Options}
+	for _, word := range words[:len(words)-1] {
+		s.advance(word)
+	}
+
+	if s.argument != nil {
+		return values(*s.argument, partial)
+	}
+	if s.until != nil {
+		return values(*s.until, partial)
+	}
+	if s.operands {
+		return nil
+	}
+
+	var candidates []string
+	option, at := s.attached(partial)
+	switch {
+	case option != nil:
+		for _, value := range values(*option, partial[at:]) {
+			candidates = append(candidates, partial[:at]+value)
+		}
+
+	case strings.HasPrefix(partial, "--") && strings.Contains(partial, "="):
+		eq := strings.IndexByte(partial, '=')
+		for _, option := range s.options {
+			if option.Long == partial[2:eq] && option.Kind != optparse.KindNone {
+				for _, value := range values(option, partial[eq+1:]) {
+					candidates = append(candidates, partial[:eq+1]+value)
+				}
+			}
+		}
+
+	case strings.HasPrefix(partial, "-"):
+		for _, option := range s.options {
+			if option.Prefix {
+				continue
+			}
+			if option.Short != 0 && strings.HasPrefix("-"+string(option.Short), partial) {
+				candidates = append(candidates, "-"+string(option.Short))
+			}
+			if option.Long != "" && strings.HasPrefix("--"+option.Long, partial) {
+				candidates = append(candidates, "--"+option.Long)
+			}
+		}
+
+	default:
+		for _, sub := range s.cmd.Commands {
+			for _, name := range append([]string{sub.Name}, sub.Aliases...) {
+				if strings.HasPrefix(name, partial) {
+					candidates = append(candidates, name)
+				}
+			}
+		}
+	}
+	return candidates
+}
+
+// values returns candidate arguments for an option.
+func values(option optparse.Option, prefix string) []string {
+	var candidates []string
+	for _, choice := range option.Choices {
+		if strings.HasPrefix(choice, prefix) {
+			candidates = append(candidates, choice)
+		}
+	}
+	if option.Complete != nil {
+		candidates = append(candidates, option.Complete(prefix)...)
+	}
+	switch option.CompleteAs {
+	case optparse.HintFiles:
+		candidates = append(candidates, paths(prefix, false)...)
+	case optparse.HintDirs:
+		candidates = append(candidates, paths(prefix, true)...)
+	}
+	return candidates
+}
+
+// paths lists the files, or only directories, beginning with prefix.
+// Directories are marked with a trailing slash.
+func paths(prefix string, dirs bool) []string {
+	dir, base := filepath.Split(prefix)
+	entries, err := os.ReadDir(filepath.Join(".", dir))
+	if err != nil {
+		return nil
+	}
+	var paths []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasPrefix(name, base) || strings.HasPrefix(name, ".") && !strings.HasPrefix(base, ".") {
+			continue
+		}
+		switch {
+		case entry.IsDir():
+			paths = append(paths, dir+name+"/")
+		case !dirs:
+			paths = append(paths, dir+name)
+		}
+	}
+	return paths
+}
+
+// state tracks the parse of a partial command line.
+type state struct {
+	cmd      *optparse.Command
+	options  []optparse.Option // including inherited
+	argument *optparse.Option  // awaiting a separate argument
+	until    *optparse.Option  // taking arguments through its terminator
+	operands bool              // options have ended
+}
+
+// advance consumes a complete word.
+func (s *state) advance(word string) {
+	switch {
+	case s.argument != nil:
+		s.argument = nil
+
+	case s.until != nil:
+		terminator := s.until.Until
+		if terminator == "" {
+			terminator = ";"
+		}
+		if word == terminator {
+			s.until = nil
+		}
+
+	case s.operands:
+
+	case word == "--":
+		s.operands = true
+
+	case strings.HasPrefix(word, "--"):
+		name, _, attached := strings.Cut(word[2:], "=")
+		for i, option := range s.options {
+			switch {
+			case option.Long != name:
+			case option.Kind == optparse.KindUntil:
+				s.until = &s.options[i]
+			case option.Kind == optparse.KindRequired && !attached:
+				s.argument = &s.options[i]
+			}
+		}
+
+	case strings.HasPrefix(word, "-") && len(word) > 1:
+		for i, r := range word[1:] {
+			option := s.short(r)
+			if option != nil && option.Kind != optparse.KindNone {
+				switch {
+				case option.Kind == optparse.KindUntil:
+					s.until = option
+				case option.Kind == optparse.KindRequired && i+1+len(string(r)) == len(word):
+					s.argument = option
+				}
+				return // remainder is the argument
+			}
+		}
+
+	default:
+		for _, sub := range s.cmd.Commands {
+			if sub.Name == word || slices.Contains(sub.Aliases, word) {
+				var inherited []optparse.Option
+				for _, option := range s.options {
+					if option.Persistent {
+						inherited = append(inherited, option)
+					}
+				}
+				s.cmd = sub
+				s.options = append(sub.Options[:len(sub.Options):len(sub.Options)], inherited...)
+				return
+			}
+		}
+		s.operands = true
+	}
+}
+
+// attached returns the option taking an argument within a cluster of
+// short options, such as -m in -vmfoo, and the offset of its argument,
+// provided the argument is under way.
+func (s *state) attached(cluster string) (*optparse.Option, int) {
+	if !strings.HasPrefix(cluster, "-") || strings.HasPrefix(cluster, "--") {
+		return nil, 0
+	}
+	for i, r := range cluster[1:] {
+		option := s.short(r)
+		if option == nil {
+			return nil, 0
+		}
+		if option.Kind == optparse.KindNone {
+			continue
+		}
+		if at := 1 + i + len(string(r)); at < len(cluster) {
+			return option, at
+		}
+		return nil, 0
+	}
+	return nil, 0
+}
+
+// short returns the option with the given short name.
+func (s *state) short(r rune) *optparse.Option {
+	for i, option := range s.options {
+		if option.Short == r {
+			return &s.options[i]
+		}
+	}
+	return nil
+}
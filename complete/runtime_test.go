@@ -0,0 +1,203 @@
+package complete
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"nullprogram.com/x/optparse"
+)
+
+func TestCandidates(t *testing.T) {
+	table := []struct {
+		words []string
+		want  []string
+	}{
+		{nil, []string{"commit", "ci", "status"}},
+		{[]string{"c"}, []string{"commit", "ci"}},
+		{[]string{"--c"}, []string{"--color"}},
+		{[]string{"-"}, []string{"-v", "--verbose", "--color", "--no-color"}},
+		{[]string{"-v"}, []string{"-v"}},
+		{[]string{"commit", "-m"}, []string{"-m"}},
+		{[]string{"--color=a"}, nil},
+		{[]string{"-v", "commit", "--"}, []string{"--message", "--verbose"}},
+		{[]string{"ci", "-m", ""}, nil},
+		{[]string{"ci", "-vm", ""}, nil},
+		{[]string{"ci", "-mx", ""}, nil},
+		{[]string{"ci", "-mx", "-"}, []string{"-m", "--message", "-v", "--verbose"}},
+		{[]string{"ci", "--", "-"}, nil},
+		{[]string{"file", "c"}, nil},
+		{[]string{"status", "s"}, nil},
+	}
+	for _, row := range table {
+		got := Candidates(command, row.words)
+		if !reflect.DeepEqual(got, row.want) {
+			t.Errorf("Candidates(%q), got %q, want %q", row.words, got, row.want)
+		}
+	}
+}
+
+func TestLine(t *testing.T) {
+	table := []struct {
+		line string
+		want []string
+	}{
+		{"", []string{""}},
+		{"vcs ", []string{"vcs", ""}},
+		{"vcs\tst", []string{"vcs", "st"}},
+		{"vcs ci --msg 'a b' --", []string{"vcs", "ci", "--msg", "a b", "--"}},
+		{`vcs ci --msg "a \"b\"" `, []string{"vcs", "ci", "--msg", `a "b"`, ""}},
+		{`vcs ci a\ b `, []string{"vcs", "ci", "a b", ""}},
+		{"vcs ci 'a b", []string{"vcs", "ci", "a b"}},
+		{`vcs ci "a b`, []string{"vcs", "ci", "a b"}},
+		{`vcs ci a\`, []string{"vcs", "ci", "a"}},
+		{"vcs ci x", []string{"vcs", "ci", "x"}},
+	}
+	for _, row := range table {
+		if got := Line(row.line); !reflect.DeepEqual(got, row.want) {
+			t.Errorf("Line(%q), got %q, want %q", row.line, got, row.want)
+		}
+	}
+}
+
+func TestCandidatesUntil(t *testing.T) {
+	cmd := &optparse.Command{
+		Name: "find",
+		Options: []optparse.Option{
+			{Long: "exec", Short: 'x', Kind: optparse.KindUntil, Choices: []string{"rm", "ls"}},
+			{Long: "batch", Kind: optparse.KindUntil, Until: "+"},
+			{Long: "verbose", Short: 'v', Kind: optparse.KindNone},
+		},
+		Commands: []*optparse.Command{{Name: "run"}},
+	}
+	table := []struct {
+		words []string
+		want  []string
+	}{
+		{[]string{"--exec", ""}, []string{"rm", "ls"}},
+		{[]string{"--exec", "rm", "-"}, nil},
+		{[]string{"--exec", "rm", ";", "-"}, []string{"-x", "--exec", "--batch", "-v", "--verbose"}},
+		{[]string{"--exec=rm", "r"}, []string{"rm"}},
+		{[]string{"-vx", "l"}, []string{"ls"}},
+		{[]string{"-x", "rm", ";", "r"}, []string{"run"}},
+		{[]string{"--batch", ";", "r"}, nil},
+		{[]string{"--batch", "x", "+", "r"}, []string{"run"}},
+	}
+	for _, row := range table {
+		got := Candidates(cmd, row.words)
+		if !reflect.DeepEqual(got, row.want) {
+			t.Errorf("Candidates(%q), got %q, want %q", row.words, got, row.want)
+		}
+	}
+}
+
+func TestHandle(t *testing.T) {
+	var buf bytes.Buffer
+	env := map[string]string{}
+	oldStdout, oldGetenv := stdout, getenv
+	stdout = &buf
+	getenv = func(key string) string { return env[key] }
+	defer func() { stdout, getenv = oldStdout, oldGetenv }()
+
+	if Handle(command, []string{"vcs", "commit"}) || buf.Len() != 0 {
+		t.Errorf("Handle(), handled an ordinary command line")
+	}
+
+	if !Handle(command, []string{"vcs", "__complete", "ci", "--m"}) {
+		t.Errorf("Handle(__complete), not handled")
+	}
+	if got := buf.String(); got != "--message\n" {
+		t.Errorf("Handle(__complete), got %q", got)
+	}
+
+	buf.Reset()
+	env["COMP_LINE"] = "vcs st --verbose"
+	env["COMP_POINT"] = "6"
+	if !Handle(command, []string{"vcs", "vcs", "st", "vcs"}) {
+		t.Errorf("Handle(COMP_LINE), not handled")
+	}
+	if got := buf.String(); got != "status\n" {
+		t.Errorf("Handle(COMP_LINE), got %q", got)
+	}
+}
+
+func TestCandidatesComplete(t *testing.T) {
+	branches := []string{"main", "master", "dev"}
+	root := &optparse.Command{
+		Name: "vcs",
+		Options: []optparse.Option{
+			{Short: 'v', Kind: optparse.KindNone},
+			{Short: 'c', Long: "color", Kind: optparse.KindRequired, Choices: []string{"auto", "always", "never"}},
+			{
+				Long:  "branch",
+				Short: 'b',
+				Kind:  optparse.KindRequired,
+				Complete: func(prefix string) []string {
+					var matches []string
+					for _, branch := range branches {
+						if strings.HasPrefix(branch, prefix) {
+							matches = append(matches, branch)
+						}
+					}
+					return matches
+				},
+			},
+		},
+	}
+	table := []struct {
+		words []string
+		want  []string
+	}{
+		{[]string{"--branch", "ma"}, []string{"main", "master"}},
+		{[]string{"-b", ""}, branches},
+		{[]string{"--branch=d"}, []string{"--branch=dev"}},
+		{[]string{"-vbm"}, []string{"-vbmain", "-vbmaster"}},
+		{[]string{"-vb"}, nil},
+		{[]string{"--color", "a"}, []string{"auto", "always"}},
+		{[]string{"-ca"}, []string{"-cauto", "-calways"}},
+	}
+	for _, row := range table {
+		got := Candidates(root, row.words)
+		if !reflect.DeepEqual(got, row.want) {
+			t.Errorf("Candidates(%q), got %q, want %q", row.words, got, row.want)
+		}
+	}
+}
+
+func TestCandidatesPaths(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "src", "lib"), 0o755)
+	os.WriteFile(filepath.Join(dir, "src", "main.go"), nil, 0o644)
+	os.WriteFile(filepath.Join(dir, "setup.sh"), nil, 0o644)
+	os.WriteFile(filepath.Join(dir, ".hidden"), nil, 0o644)
+	wd, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(wd)
+
+	root := &optparse.Command{
+		Options: []optparse.Option{
+			{Long: "file", Kind: optparse.KindRequired, CompleteAs: optparse.HintFiles},
+			{Long: "dir", Kind: optparse.KindRequired, CompleteAs: optparse.HintDirs},
+		},
+	}
+	table := []struct {
+		words []string
+		want  []string
+	}{
+		{[]string{"--file", "s"}, []string{"setup.sh", "src/"}},
+		{[]string{"--file", "src/"}, []string{"src/lib/", "src/main.go"}},
+		{[]string{"--file", "."}, []string{".hidden"}},
+		{[]string{"--dir", ""}, []string{"src/"}},
+		{[]string{"--dir=src/"}, []string{"--dir=src/lib/"}},
+		{[]string{"--dir", "nonexistent/"}, nil},
+	}
+	for _, row := range table {
+		got := Candidates(root, row.words)
+		if !reflect.DeepEqual(got, row.want) {
+			t.Errorf("Candidates(%q), got %q, want %q", row.words, got, row.want)
+		}
+	}
+}
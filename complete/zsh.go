@@ -0,0 +1,139 @@
+// This is free and unencumbered software released into the public domain.
+
+package complete
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"nullprogram.com/x/optparse"
+)
+
+// Zsh writes a zsh completion script for the command, built on
+// _arguments, with a function for each command in the tree. An option
+// excludes its other names and, for pairs like --color and --no-color,
+// the names of its negation. Arguments complete as the option's
+// Choices, if any, or else according to CompleteAs. Install the script
+// as _NAME in a directory on $fpath.
+func Zsh(w io.Writer, cmd *optparse.Command) error {
+	b := bufio.NewWriter(w)
+	fmt.Fprintf(b, "#compdef %s\n", cmd.Name)
+	walk(cmd, func(n node) {
+		fn := identifier(n.path)
+		fmt.Fprintf(b, "\n%s() {\n", fn)
+		if len(n.cmd.Commands) == 0 {
+			fmt.Fprint(b, "\t_arguments -s -S \\\n")
+			for _, option := range n.options {
+				zshOption(b, n.options, option)
+			}
+			fmt.Fprint(b, "\t\t'*: :_default'\n}\n")
+			return
+		}
+
+		fmt.Fprint(b, "\tlocal context state state_descr line\n")
+		fmt.Fprint(b, "\ttypeset -A opt_args\n")
+		fmt.Fprint(b, "\t_arguments -s -S -C \\\n")
+		for _, option := range n.options {
+			zshOption(b, n.options, option)
+		}
+		fmt.Fprint(b, "\t\t'1: :->command' \\\n")
+		fmt.Fprint(b, "\t\t'*:: :->args'\n")
+		fmt.Fprint(b, "\tcase $state in\n")
+		fmt.Fprint(b, "\tcommand)\n")
+		fmt.Fprint(b, "\t\tlocal -a commands\n")
+		fmt.Fprint(b, "\t\tcommands=(\n")
+		for _, sub := range n.cmd.Commands {
+			for _, name := range append([]string{sub.Name}, sub.Aliases...) {
+				desc := strings.ReplaceAll(name, ":", `\:`)
+				if sub.Help != "" {
+					desc += ":" + sub.Help
+				}
+				fmt.Fprintf(b, "\t\t\t%s\n", quote(desc))
+			}
+		}
+		fmt.Fprint(b, "\t\t)\n")
+		fmt.Fprint(b, "\t\t_describe -t commands command commands\n")
+		fmt.Fprint(b, "\t\t;;\n")
+		fmt.Fprint(b, "\targs)\n")
+		fmt.Fprint(b, "\t\tcase $line[1] in\n")
+		for _, sub := range n.cmd.Commands {
+			subpath := append(n.path[:len(n.path):len(n.path)], sub.Name)
+			patterns := strings.Join(append([]string{sub.Name}, sub.Aliases...), "|")
+			fmt.Fprintf(b, "\t\t%s) %s ;;\n", patterns, identifier(subpath))
+		}
+		fmt.Fprint(b, "\t\tesac\n")
+		fmt.Fprint(b, "\t\t;;\n")
+		fmt.Fprint(b, "\tesac\n}\n")
+	})
+	fmt.Fprintf(b, "\n%s \"$@\"\n", identifier([]string{cmd.Name}))
+	return b.Flush()
+}
+
+// zshOption writes the _arguments specifications for an option, one
+// for each of its names.
+func zshOption(b *bufio.Writer, options []optparse.Option, option optparse.Option) {
+	if option.Prefix {
+		return // open-ended names cannot be listed
+	}
+	exclude := names(option)
+	if neg := negation(options, option); neg != nil {
+		exclude = append(exclude, names(*neg)...)
+	}
+	arg := strings.NewReplacer(`\`, `\\`, ":", `\:`).Replace(option.ArgName)
+	if arg == "" {
+		arg = "ARG"
+	}
+	help := strings.NewReplacer(`\`, `\\`, "[", `\[`, "]", `\]`).Replace(option.Help)
+	var action string
+	switch {
+	case option.Choices != nil:
+		var choices []string
+		for _, choice := range option.Choices {
+			choices = append(choices, strings.NewReplacer(`\`, `\\`, " ", `\ `, ")", `\)`).Replace(choice))
+		}
+		action = "(" + strings.Join(choices, " ") + ")"
+	case option.CompleteAs == optparse.HintFiles:
+		action = "_files"
+	case option.CompleteAs == optparse.HintDirs:
+		action = "_files -/"
+	}
+
+	for _, name := range names(option) {
+		long := strings.HasPrefix(name, "--")
+		var spec string
+		switch {
+		case option.Kind == optparse.KindRequired && long:
+			spec = name + "=[" + help + "]:" + arg + ":" + action
+		case option.Kind == optparse.KindRequired:
+			spec = name + "+[" + help + "]:" + arg + ":" + action
+		case option.Kind == optparse.KindOptional && long:
+			spec = name + "=-[" + help + "]::" + arg + ":" + action
+		case option.Kind == optparse.KindOptional:
+			spec = name + "-[" + help + "]::" + arg + ":" + action
+		case option.Kind == optparse.KindUntil:
+			spec = name + "[" + help + "]:*" + zshPattern(option) + ":" + arg + ":" + action
+		default:
+			spec = name + "[" + help + "]"
+		}
+		fmt.Fprintf(b, "\t\t%s \\\n", quote("("+strings.Join(exclude, " ")+")"+spec))
+	}
+}
+
+// zshPattern returns the terminator of a KindUntil option as a zsh
+// pattern matching it literally.
+func zshPattern(option optparse.Option) string {
+	until := option.Until
+	if until == "" {
+		until = ";"
+	}
+	var b strings.Builder
+	for _, r := range until {
+		if strings.ContainsRune(`\*?[]<>()|^#~:;`, r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,120 @@
+package complete
+
+import (
+	"bytes"
+	"testing"
+
+	"nullprogram.com/x/optparse"
+)
+
+var command = &optparse.Command{
+	Name: "vcs",
+	Options: []optparse.Option{
+		{Long: "verbose", Short: 'v', Kind: optparse.KindNone, Help: "explain [more]", Persistent: true},
+		{Long: "color", Kind: optparse.KindOptional, Help: "colorize"},
+		{Long: "no-color", Kind: optparse.KindNone},
+	},
+	Commands: []*optparse.Command{
+		{
+			Name:    "commit",
+			Aliases: []string{"ci"},
+			Help:    "record changes",
+			Options: []optparse.Option{
+				{Long: "message", Short: 'm', Kind: optparse.KindRequired, Help: "use 'this' message"},
+			},
+		},
+		{Name: "status"},
+	},
+}
+
+func TestZsh(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Zsh(&buf, command); err != nil {
+		t.Fatal(err)
+	}
+	want := `#compdef vcs
+
+_vcs() {
+	local context state state_descr line
+	typeset -A opt_args
+	_arguments -s -S -C \
+		'(-v --verbose)-v[explain \[more\]]' \
+		'(-v --verbose)--verbose[explain \[more\]]' \
+		'(--color --no-color)--color=-[colorize]::ARG:' \
+		'(--no-color --color)--no-color[]' \
+		'1: :->command' \
+		'*:: :->args'
+	case $state in
+	command)
+		local -a commands
+		commands=(
+			'commit:record changes'
+			'ci:record changes'
+			'status'
+		)
+		_describe -t commands command commands
+		;;
+	args)
+		case $line[1] in
+		commit|ci) _vcs_commit ;;
+		status) _vcs_status ;;
+		esac
+		;;
+	esac
+}
+
+_vcs_commit() {
+	_arguments -s -S \
+		'(-m --message)-m+[use '\''this'\'' message]:ARG:' \
+		'(-m --message)--message=[use '\''this'\'' message]:ARG:' \
+		'(-v --verbose)-v[explain \[more\]]' \
+		'(-v --verbose)--verbose[explain \[more\]]' \
+		'*: :_default'
+}
+
+_vcs_status() {
+	_arguments -s -S \
+		'(-v --verbose)-v[explain \[more\]]' \
+		'(-v --verbose)--verbose[explain \[more\]]' \
+		'*: :_default'
+}
+
+_vcs "$@"
+`
+	if got := buf.String(); got != want {
+		t.Errorf("Zsh(), got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestZshHints(t *testing.T) {
+	root := &optparse.Command{
+		Name: "tar",
+		Options: []optparse.Option{
+			{Long: "file", Short: 'f', Kind: optparse.KindRequired, CompleteAs: optparse.HintFiles},
+			{Short: 'C', Kind: optparse.KindRequired, CompleteAs: optparse.HintDirs},
+			{Long: "format", Kind: optparse.KindOptional, Choices: []string{"gnu", "new ustar"}},
+			{Long: "exec", Kind: optparse.KindUntil, ArgName: "CMD"},
+			{Short: 'x', Kind: optparse.KindUntil, Until: "+*"},
+		},
+	}
+	var buf bytes.Buffer
+	Zsh(&buf, root)
+	want := `#compdef tar
+
+_tar() {
+	_arguments -s -S \
+		'(-f --file)-f+[]:ARG:_files' \
+		'(-f --file)--file=[]:ARG:_files' \
+		'(-C)-C+[]:ARG:_files -/' \
+		'(--format)--format=-[]::ARG:(gnu new\ ustar)' \
+		'(--exec)--exec[]:*\;:CMD:' \
+		'(-x)-x[]:*+\*:ARG:' \
+		'*: :_default'
+}
+
+_tar "$@"
+`
+	if got := buf.String(); got != want {
+		t.Errorf("Zsh(), got:\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -0,0 +1,242 @@
+// This is free and unencumbered software released into the public domain.
+
+package optparse
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// ConfigError is an error in a config file. File is the file's path,
+// if known, Line is the line number, counting from 1, and Err is
+// typically an Error for the offending option. Implements error.
+type ConfigError struct {
+	File string
+	Line int
+	Err  error
+}
+
+func (e ConfigError) Error() string {
+	if e.File != "" {
+		return fmt.Sprintf("%s:%d: %s", e.File, e.Line, e.Err)
+	}
+	return fmt.Sprintf("line %d: %s", e.Line, e.Err)
+}
+
+// Unwrap returns Err so that errors.Is(err, ErrInvalid) and the like
+// identify the kind of failure.
+func (e ConfigError) Unwrap() error {
+	return e.Err
+}
+
+// Merge combines command-line results with those read from a config
+// file, file, such that the command line wins: file results for options
+// also given on the command line are dropped. The remaining file results
+// come first, as though given before the command-line options.
+func (r Results) Merge(file Results) Results {
+	merged := make(Results, 0, len(file)+len(r))
+	for _, result := range file {
+		if !slices.ContainsFunc(r, result.Option.defines) {
+			merged = append(merged, result)
+		}
+	}
+	return append(merged, r...)
+}
+
+// ParseConfigFile reads the config file at path with ParseINI,
+// ParseTOML, ParseYAML, or ParseJSON according to its extension: .toml,
+// .yaml or .yml, .json, or otherwise INI, as with traditional rc files.
+// ConfigErrors name the file.
+func ParseConfigFile(path string, options []Option) (Results, error) {
+	var results Results
+	err := readConfigFile(path, func(entries []entry) (err error) {
+		results, err = apply(options, entries)
+		return
+	})
+	return results, err
+}
+
+// MergeConfigFile is like ParseConfigFile but merges the file into the
+// invocation's results as with MergeTOML.
+func (inv *Invocation) MergeConfigFile(path string) error {
+	return readConfigFile(path, inv.merge)
+}
+
+// readConfigFile reads the entries of the config file at path and
+// passes them to f, naming the file in any ConfigError.
+func readConfigFile(path string, f func([]entry) error) error {
+	read := readINI
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".toml":
+		read = readTOML
+	case ".yaml", ".yml":
+		read = readYAML
+	case ".json":
+		read = readJSON
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	entries, err := read(file)
+	if ferr := f(entries); ferr != nil {
+		err = ferr
+	}
+	if cerr, ok := err.(ConfigError); ok {
+		cerr.File = path
+		err = cerr
+	}
+	return err
+}
+
+// parseConfig reads entries with read and returns their results for
+// the options, up to the first error.
+func parseConfig(read func(io.Reader) ([]entry, error), r io.Reader, options []Option) (Results, error) {
+	entries, err := read(r)
+	results, aerr := apply(options, entries)
+	if aerr != nil {
+		return results, aerr
+	}
+	return results, err
+}
+
+// entry is a setting read from a config file, naming an option by the
+// dotted path of its table or section and key.
+type entry struct {
+	path  []string
+	value string
+	set   bool // value given
+	line  int
+}
+
+// apply returns the results of the entries for the options, naming
+// each option by its full dotted path.
+func apply(options []Option, entries []entry) (Results, error) {
+	var results Results
+	for _, e := range entries {
+		result, ok, err := setting(options, strings.Join(e.path, "."), e.value, e.set, e.line)
+		if err != nil {
+			return results, err
+		}
+		if ok {
+			results = append(results, result)
+		}
+	}
+	return results, nil
+}
+
+// merge merges the entries into the invocation's results as with
+// Results.Merge. A leading path element naming the next selected
+// command descends to that command, and entries for subcommands not
+// selected are ignored. Entries not matching one of a command's own
+// options may set a persistent option of an ancestor.
+func (inv *Invocation) merge(entries []entry) error {
+	file := make([]Results, len(inv.Commands))
+	for _, e := range entries {
+		level, path := 0, e.path
+		for ; len(path) > 1 && level+1 < len(inv.Commands); level++ {
+			if inv.Commands[level+1].Name != path[0] {
+				break
+			}
+			path = path[1:]
+		}
+		if len(path) > 1 && inv.Commands[level].find(path[0]) != nil {
+			continue // another subcommand
+		}
+
+		name := strings.Join(path, ".")
+		for i := level; i > 0 && lookup(inv.Commands[i].Options, name) == nil; i-- {
+			if option := lookup(inv.Commands[i-1].Options, name); option != nil && option.Persistent {
+				level = i - 1
+				break
+			}
+		}
+		result, ok, err := setting(inv.Commands[level].Options, name, e.value, e.set, e.line)
+		if err != nil {
+			return err
+		}
+		if ok {
+			file[level] = append(file[level], result)
+		}
+	}
+	for i := range file {
+		inv.Results[i] = inv.Results[i].Merge(file[i])
+	}
+	return nil
+}
+
+// lookup finds the option with the given long name, including by
+// Prefix.
+func lookup(options []Option, name string) *Option {
+	if option := findLong(options, name); option != nil {
+		return option
+	}
+	return findPrefix(options, name)
+}
+
+// setting returns the result of a config file setting the named option
+// to value as with assign.
+func setting(options []Option, name, value string, set bool, line int) (Result, bool, error) {
+	option := lookup(options, name)
+	if option == nil {
+		return Result{}, false, ConfigError{Line: line, Err: Error{
+			Option: Option{Long: name},
+			Err:    ErrInvalid,
+		}}
+	}
+	result, ok, err := assign(*option, value, set)
+	if err != nil {
+		return result, false, ConfigError{Line: line, Err: Error{Option: *option, Err: err}}
+	}
+	result.Index = line
+	result.Raw = []string{name}
+	result.Source = SourceConfig
+	return result, ok, nil
+}
+
+// assign returns the result of setting the option to value outside the
+// command line, where set is false if no value was given. Options
+// without an argument accept a boolean value, including yes/no and
+// on/off, and false produces no result, which the boolean result
+// reports.
+func assign(option Option, value string, set bool) (Result, bool, error) {
+	result := Result{Option: option, Optarg: value, Attached: set}
+	switch {
+	case option.Kind == KindNone && !set:
+		result.Optarg = ""
+		return result, true, nil
+	case option.Kind == KindNone:
+		switch value = strings.ToLower(value); value {
+		case "yes", "on":
+			value = "true"
+		case "no", "off":
+			value = "false"
+		}
+		on, err := convertBool(value)
+		if err != nil {
+			return Result{}, false, err
+		}
+		result.Optarg = ""
+		result.Attached = false
+		return result, on, nil
+	case (option.Kind == KindRequired || option.Kind == KindUntil) && !set:
+		return Result{}, false, ErrMissing
+	case option.Kind == KindUntil:
+		optargs, err := SplitLine(value)
+		if err != nil {
+			return Result{}, false, err
+		}
+		result.Optarg, result.Optargs = "", optargs
+		return result, true, nil
+	}
+	if err := check(&result); err != nil {
+		return Result{}, false, err
+	}
+	return result, true, nil
+}
@@ -0,0 +1,94 @@
+package optparse
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMerge(t *testing.T) {
+	options := []Option{
+		{Long: "color", Kind: KindRequired},
+		{Long: "include", Short: 'I', Kind: KindRequired},
+		{Long: "verbose", Short: 'v', Kind: KindNone},
+	}
+	file, err := ParseINI(strings.NewReader("color=never\ninclude=a\ninclude=b\nverbose\n"), options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cli, _, err := Parse(options, []string{"", "-I", "c", "--color=always"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	merged := cli.Merge(file)
+	if got, _ := merged.Get("color"); got != "always" {
+		t.Errorf(`Get("color"), got %q, want "always"`, got)
+	}
+	if got, want := merged.GetAll("include"), []string{"c"}; !equal(got, want) {
+		t.Errorf(`GetAll("include"), got %q, want %q`, got, want)
+	}
+	if !merged.Has("verbose") || merged[0].Long != "verbose" {
+		t.Errorf("Merge(), got %v, want verbose first", merged)
+	}
+}
+
+func TestParseConfigFile(t *testing.T) {
+	options := []Option{
+		{Long: "color", Kind: KindRequired},
+		{Long: "net.delay", Kind: KindRequired},
+	}
+	files := []struct {
+		name, content, color string
+	}{
+		{"app.ini", "color = ini\n[net]\ndelay = 1\n", "ini"},
+		{".apprc", "color = rc\n[net]\ndelay = 1\n", "rc"},
+		{"app.toml", "color = 'toml'\n[net]\ndelay = 1\n", "toml"},
+		{"app.YML", "color: yaml\nnet:\n  delay: 1\n", "yaml"},
+		{"app.json", `{"color": "json", "net": {"delay": 1}}`, "json"},
+	}
+	dir := t.TempDir()
+	for _, file := range files {
+		path := filepath.Join(dir, file.name)
+		if err := os.WriteFile(path, []byte(file.content), 0o666); err != nil {
+			t.Fatal(err)
+		}
+		results, err := ParseConfigFile(path, options)
+		if err != nil {
+			t.Errorf("ParseConfigFile(%q), got %v", file.name, err)
+			continue
+		}
+		color, _ := results.Get("color")
+		delay, _ := results.Get("net.delay")
+		if color != file.color || delay != "1" {
+			t.Errorf("ParseConfigFile(%q), got %q %q", file.name, color, delay)
+		}
+	}
+
+	path := filepath.Join(dir, "bad.toml")
+	if err := os.WriteFile(path, []byte("\nbogus = 1\n"), 0o666); err != nil {
+		t.Fatal(err)
+	}
+	_, err := ParseConfigFile(path, options)
+	if want := path + ":2: invalid option: --bogus"; err == nil || err.Error() != want {
+		t.Errorf("ParseConfigFile(), got %v, want %q", err, want)
+	}
+	if _, err := ParseConfigFile(filepath.Join(dir, "missing.ini"), options); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("ParseConfigFile(), got %v, want %v", err, fs.ErrNotExist)
+	}
+
+	root := &Command{Name: "app", Options: options}
+	inv, err := root.Parse([]string{"app"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := inv.MergeConfigFile(filepath.Join(dir, "app.json")); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := inv.Results[0].Get("color"); got != "json" {
+		t.Errorf("MergeConfigFile(), got %q, want json", got)
+	}
+}
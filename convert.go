@@ -0,0 +1,169 @@
+// This is free and unencumbered software released into the public domain.
+
+package optparse
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Type is an enumeration of option argument types for conversion by
+// the parser.
+type Type int
+
+const (
+	// TypeString leaves the argument unconverted.
+	TypeString Type = iota
+	// TypeInt converts to int, accepting base prefixes like 0x.
+	TypeInt
+	// TypeUint converts to uint, accepting base prefixes like 0x.
+	TypeUint
+	// TypeFloat converts to float64.
+	TypeFloat
+	// TypeBool converts to bool as with strconv.ParseBool.
+	TypeBool
+	// TypeDuration converts to time.Duration as with time.ParseDuration.
+	TypeDuration
+)
+
+// check converts and validates a result's argument according to its
+// option.
+func check(result *Result) error {
+	hasArg := result.Kind == KindRequired || result.Attached || result.Optarg != ""
+	if !hasArg || result.Kind == KindUntil {
+		return nil
+	}
+
+	var err error
+	result.Value, err = convert(result.Type, result.Optarg)
+	if err != nil {
+		return masked(result.Option, err)
+	}
+	shown := result.Optarg
+	if result.Sensitive {
+		shown = mask
+	}
+
+	if result.Min != 0 || result.Max != 0 {
+		v := math.NaN()
+		switch value := result.Value.(type) {
+		case int:
+			v = float64(value)
+		case uint:
+			v = float64(value)
+		case float64:
+			v = value
+		}
+		if v < result.Min || v > result.Max {
+			return fmt.Errorf("argument %q is not between %v and %v",
+				shown, result.Min, result.Max)
+		}
+	}
+
+	if len(result.Choices) > 0 && !slices.Contains(result.Choices, result.Optarg) {
+		return fmt.Errorf("argument %q is not one of %s",
+			shown, strings.Join(result.Choices, ", "))
+	}
+
+	if result.Pattern != nil && !result.Pattern.MatchString(result.Optarg) {
+		return fmt.Errorf("argument %q does not match %s",
+			shown, result.Pattern)
+	}
+
+	if result.Validate != nil {
+		return result.Validate(result.Optarg)
+	}
+	return nil
+}
+
+// convert converts s according to t, returning nil for TypeString or on
+// error.
+func convert(t Type, s string) (any, error) {
+	var v any
+	var err error
+	switch t {
+	case TypeString:
+		return nil, nil
+	case TypeInt:
+		v, err = convertInt(s)
+	case TypeUint:
+		v, err = convertUint(s)
+	case TypeFloat:
+		v, err = convertFloat(s)
+	case TypeBool:
+		v, err = convertBool(s)
+	case TypeDuration:
+		v, err = convertDuration(s)
+	default:
+		panic("invalid Type")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// Conversions of option arguments, following the flag package's syntax
+// for each type but with brief error messages meant to be wrapped in an
+// Error identifying the option.
+
+func convertInt(s string) (int, error) {
+	v, err := strconv.ParseInt(s, 0, strconv.IntSize)
+	return int(v), convertError("integer", s, err)
+}
+
+func convertUint(s string) (uint, error) {
+	v, err := strconv.ParseUint(s, 0, strconv.IntSize)
+	return uint(v), convertError("unsigned integer", s, err)
+}
+
+func convertFloat(s string) (float64, error) {
+	v, err := strconv.ParseFloat(s, 64)
+	return v, convertError("number", s, err)
+}
+
+func convertBool(s string) (bool, error) {
+	v, err := strconv.ParseBool(s)
+	return v, convertError("boolean", s, err)
+}
+
+func convertDuration(s string) (time.Duration, error) {
+	v, err := time.ParseDuration(s)
+	return v, convertError("duration", s, err)
+}
+
+func convertError(kind, s string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return argError{kind, s, errors.Is(err, strconv.ErrRange)}
+}
+
+// argError is a failed conversion of the argument s to a kind of value,
+// which may be masked.
+type argError struct {
+	kind  string
+	s     string
+	large bool // out of range
+}
+
+func (e argError) Error() string {
+	if e.large {
+		return fmt.Sprintf("%s out of range %q", e.kind, e.s)
+	}
+	return fmt.Sprintf("invalid %s %q", e.kind, e.s)
+}
+
+// masked returns err with the argument of a Sensitive option masked.
+func masked(option Option, err error) error {
+	if e, ok := err.(argError); ok && option.Sensitive {
+		e.s = mask
+		return e
+	}
+	return err
+}
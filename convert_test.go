@@ -0,0 +1,163 @@
+package optparse
+
+import (
+	"errors"
+	"math"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestType(t *testing.T) {
+	options := []Option{
+		{Long: "jobs", Short: 'j', Kind: KindRequired, Type: TypeInt},
+		{Long: "size", Short: 's', Kind: KindRequired, Type: TypeUint},
+		{Long: "ratio", Short: 'r', Kind: KindRequired, Type: TypeFloat},
+		{Long: "color", Short: 'c', Kind: KindOptional, Type: TypeBool},
+		{Long: "timeout", Short: 't', Kind: KindRequired, Type: TypeDuration},
+		{Long: "name", Short: 'n', Kind: KindRequired},
+	}
+
+	args := []string{"", "-j", "-3", "-s0x10", "--ratio=.5", "-c",
+		"--color=false", "-t", "2s", "-n", "x"}
+	results, _, err := Parse(options, args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []any{-3, uint(16), 0.5, nil, false, 2 * time.Second, nil}
+	if len(results) != len(want) {
+		t.Fatalf("Parse(%q), got %d results, want %d", args[1:], len(results), len(want))
+	}
+	for i, result := range results {
+		if result.Value != want[i] {
+			t.Errorf("Parse(%q)[%d], got %#v, want %#v", args[1:], i, result.Value, want[i])
+		}
+	}
+
+	table := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"", "--jobs", "ten"}, `invalid integer "ten": --jobs (-j)`},
+		{[]string{"", "-s-1"}, `invalid unsigned integer "-1": --size (-s)`},
+		{[]string{"", "-r", "1e999"}, `number out of range "1e999": --ratio (-r)`},
+		{[]string{"", "--color="}, `invalid boolean "": --color (-c)`},
+		{[]string{"", "-j1", "-t", "soon"}, `invalid duration "soon": --timeout (-t)`},
+	}
+	for _, row := range table {
+		_, _, err := Parse(options, row.args)
+		var e Error
+		if !errors.As(err, &e) || e.Error() != row.want {
+			t.Errorf("Parse(%q), got %v, want %q", row.args[1:], err, row.want)
+		}
+	}
+}
+
+func TestChoices(t *testing.T) {
+	choices := []string{"auto", "always", "never"}
+	options := []Option{
+		{Long: "color", Short: 'c', Kind: KindOptional, Choices: choices},
+		{Long: "level", Short: 'l', Kind: KindRequired, Type: TypeInt,
+			Choices: []string{"1", "2", "3"}},
+	}
+
+	results, _, err := Parse(options, []string{"", "-c", "--color=never", "-l2"})
+	if err != nil || len(results) != 3 || results[2].Value != 2 {
+		t.Errorf("Parse(), got %v %v", results, err)
+	}
+
+	table := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"", "--color=red"}, `argument "red" is not one of auto, always, never: --color (-c)`},
+		{[]string{"", "-c="}, `argument "=" is not one of auto, always, never: --color (-c)`},
+		{[]string{"", "-l", "4"}, `argument "4" is not one of 1, 2, 3: --level (-l)`},
+	}
+	for _, row := range table {
+		_, _, err := Parse(options, row.args)
+		if err == nil || err.Error() != row.want {
+			t.Errorf("Parse(%q), got %v, want %q", row.args[1:], err, row.want)
+		}
+	}
+}
+
+func TestPattern(t *testing.T) {
+	options := []Option{
+		{Long: "name", Short: 'n', Kind: KindRequired,
+			Pattern: regexp.MustCompile(`^[a-z][a-z0-9-]*$`)},
+	}
+
+	results, _, err := Parse(options, []string{"", "-n", "web-01"})
+	if err != nil || len(results) != 1 {
+		t.Errorf("Parse(), got %v %v", results, err)
+	}
+
+	_, _, err = Parse(options, []string{"", "--name=Web_01"})
+	want := `argument "Web_01" does not match ^[a-z][a-z0-9-]*$: --name (-n)`
+	if err == nil || err.Error() != want {
+		t.Errorf("Parse(), got %v, want %q", err, want)
+	}
+}
+
+func TestRange(t *testing.T) {
+	options := []Option{
+		{Long: "jobs", Short: 'j', Kind: KindRequired, Type: TypeInt, Min: 1, Max: 256},
+		{Long: "ratio", Short: 'r', Kind: KindRequired, Type: TypeFloat, Min: 0, Max: 1},
+		{Long: "size", Short: 's', Kind: KindRequired, Type: TypeUint, Min: 4, Max: math.Inf(1)},
+	}
+
+	args := []string{"", "-j1", "-j256", "-r0", "-r1", "-r0.5", "-s4", "-s1000000"}
+	if _, _, err := Parse(options, args); err != nil {
+		t.Errorf("Parse(%q), got %v", args[1:], err)
+	}
+
+	table := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"", "-j0"}, `argument "0" is not between 1 and 256: --jobs (-j)`},
+		{[]string{"", "--jobs=257"}, `argument "257" is not between 1 and 256: --jobs (-j)`},
+		{[]string{"", "-r", "1.5"}, `argument "1.5" is not between 0 and 1: --ratio (-r)`},
+		{[]string{"", "-s3"}, `argument "3" is not between 4 and +Inf: --size (-s)`},
+	}
+	for _, row := range table {
+		_, _, err := Parse(options, row.args)
+		if err == nil || err.Error() != row.want {
+			t.Errorf("Parse(%q), got %v, want %q", row.args[1:], err, row.want)
+		}
+	}
+}
+
+func TestValidateFunc(t *testing.T) {
+	errOdd := errors.New("must be even")
+	var calls []string
+	options := []Option{
+		{Long: "count", Short: 'n', Kind: KindRequired, Type: TypeInt,
+			Validate: func(optarg string) error {
+				calls = append(calls, optarg)
+				if optarg[len(optarg)-1]%2 == 1 {
+					return errOdd
+				}
+				return nil
+			}},
+		{Long: "color", Short: 'c', Kind: KindOptional,
+			Validate: func(string) error { return errOdd }},
+	}
+
+	// conversion failure preempts the validator
+	Parse(options, []string{"", "-n2", "--count", "x", "-n", "3"})
+	if !equal(calls, []string{"2"}) {
+		t.Errorf("Validate calls, got %q, want %q", calls, []string{"2"})
+	}
+
+	_, _, err := Parse(options, []string{"", "-n2", "-n", "3"})
+	var e Error
+	if !errors.Is(err, errOdd) || !errors.As(err, &e) || e.Long != "count" || e.Index != 2 {
+		t.Errorf("Parse(), got %#v, want %v", err, errOdd)
+	}
+
+	if _, _, err := Parse(options, []string{"", "-c"}); err != nil {
+		t.Errorf("Parse(), validated a missing optional argument: %v", err)
+	}
+}
@@ -0,0 +1,86 @@
+// This is free and unencumbered software released into the public domain.
+
+// Package docs generates reference documentation, such as man pages,
+// from optparse option tables and command trees, so that it stays in
+// sync with the program's actual options.
+package docs
+
+import (
+	"strings"
+
+	"nullprogram.com/x/optparse"
+)
+
+// walk calls f for cmd and each of its descendants, depth first, with
+// the names of the commands leading to it.
+func walk(cmd *optparse.Command, path []string, f func(*optparse.Command, []string)) {
+	path = append(path[:len(path):len(path)], cmd.Name)
+	f(cmd, path)
+	for _, sub := range cmd.Commands {
+		walk(sub, path, f)
+	}
+}
+
+// argument returns the placeholder for an option's argument, with
+// before and after wrapping the name, or the empty string. The name and
+// any terminator are passed through escape.
+func argument(option optparse.Option, before, after string, escape func(string) string) string {
+	name := option.ArgName
+	if name == "" {
+		name = "ARG"
+	}
+	name = before + escape(name) + after
+	switch {
+	case option.Kind == optparse.KindRequired && option.Long != "":
+		return "=" + name
+	case option.Kind == optparse.KindRequired:
+		return " " + name
+	case option.Kind == optparse.KindOptional && option.Long != "":
+		return "[=" + name + "]"
+	case option.Kind == optparse.KindOptional:
+		return "[" + name + "]"
+	case option.Kind == optparse.KindUntil:
+		until := option.Until
+		if until == "" {
+			until = ";"
+		}
+		return " " + name + "... " + escape(until)
+	}
+	return ""
+}
+
+// operands formats the command's operands for a synopsis, with a
+// leading space unless empty, with each name formatted by name.
+func operands(cmd *optparse.Command, name func(string) string) string {
+	if len(cmd.Commands) > 0 {
+		return " " + name("COMMAND") + " [" + name("ARG") + "]..."
+	}
+	var s string
+	for _, operand := range cmd.Operands {
+		part := name(operand.Name)
+		if !operand.Required && !(operand.Variadic && operand.Min > 0) {
+			part = "[" + part + "]"
+		}
+		if operand.Variadic {
+			part += "..."
+		}
+		s += " " + part
+	}
+	return s
+}
+
+// describe returns the option's help text followed by its default and
+// environment variable, if any, as in optparse.Usage.
+func describe(option optparse.Option) string {
+	var parts []string
+	if option.Help != "" {
+		parts = append(parts, option.Help)
+	}
+	if option.Default != "" {
+		parts = append(parts, "(default: "+option.Default+")")
+	}
+	if option.EnvVar != "" {
+		parts = append(parts, "[env: "+option.EnvVar+"]")
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,170 @@
+// This is free and unencumbered software released into the public domain.
+
+package docs
+
+import (
+	"encoding/json"
+	"io"
+	"math"
+
+	"nullprogram.com/x/optparse"
+)
+
+// The JSON schema written by JSON. Fields are only ever added, so that
+// consumers may rely on it.
+type (
+	jsonCommand struct {
+		Name     string        `json:"name"`
+		Aliases  []string      `json:"aliases,omitempty"`
+		Help     string        `json:"help,omitempty"`
+		Group    string        `json:"group,omitempty"`
+		Default  bool          `json:"default,omitempty"`
+		Options  []jsonOption  `json:"options"`
+		Commands []jsonCommand `json:"commands"`
+		Operands []jsonOperand `json:"operands,omitempty"`
+	}
+
+	jsonOperand struct {
+		Name     string `json:"name"`
+		Required bool   `json:"required,omitempty"`
+		Variadic bool   `json:"variadic,omitempty"`
+		Min      int    `json:"min,omitempty"`
+		Max      int    `json:"max,omitempty"`
+		Type     string `json:"type"`
+		Help     string `json:"help,omitempty"`
+	}
+
+	jsonOption struct {
+		Long       string   `json:"long,omitempty"`
+		Short      string   `json:"short,omitempty"`
+		Kind       string   `json:"kind"`
+		Until      string   `json:"until,omitempty"`
+		ArgName    string   `json:"arg,omitempty"`
+		Type       string   `json:"type"`
+		Choices    []string `json:"choices,omitempty"`
+		Pattern    string   `json:"pattern,omitempty"`
+		Min        *float64 `json:"min,omitempty"`
+		Max        *float64 `json:"max,omitempty"`
+		Required   bool     `json:"required,omitempty"`
+		Persistent bool     `json:"persistent,omitempty"`
+		Prefix     bool     `json:"prefix,omitempty"`
+		Complete   string   `json:"complete,omitempty"`
+		Default    string   `json:"default,omitempty"`
+		EnvVar     string   `json:"env,omitempty"`
+		Help       string   `json:"help,omitempty"`
+		Group      string   `json:"group,omitempty"`
+		Deprecated string   `json:"deprecated,omitempty"`
+		Sensitive  bool     `json:"sensitive,omitempty"`
+		Secret     bool     `json:"secret,omitempty"`
+	}
+)
+
+var kinds = map[optparse.Kind]string{
+	optparse.KindNone:     "none",
+	optparse.KindRequired: "required",
+	optparse.KindOptional: "optional",
+	optparse.KindUntil:    "until",
+}
+
+var hints = map[optparse.Hint]string{
+	optparse.HintFiles: "files",
+	optparse.HintDirs:  "dirs",
+}
+
+var types = map[optparse.Type]string{
+	optparse.TypeString:   "string",
+	optparse.TypeInt:      "int",
+	optparse.TypeUint:     "uint",
+	optparse.TypeFloat:    "float",
+	optparse.TypeBool:     "bool",
+	optparse.TypeDuration: "duration",
+}
+
+// JSON writes the command tree as indented JSON for consumption by
+// external tools. Each command is an object with "name", "aliases",
+// "help", "group", "default", "options", "commands", and "operands".
+// Each option has "long", "short", "kind" (none, required, optional,
+// until), "until" (the terminator of an until option), "arg", "type"
+// (string, int, uint, float, bool, duration), "choices", "pattern",
+// "min", "max", "required", "persistent", "prefix", "complete" (files,
+// dirs), "default", "env", "help", "group", "deprecated", "sensitive",
+// and "secret". Each operand has "name", "required", "variadic", "min",
+// "max", "type", and "help". Empty fields other than "kind", "type",
+// "options", and "commands" are omitted, as are infinite bounds.
+func JSON(w io.Writer, cmd *optparse.Command) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(jsonTree(cmd))
+}
+
+func jsonTree(cmd *optparse.Command) jsonCommand {
+	c := jsonCommand{
+		Name:     cmd.Name,
+		Aliases:  cmd.Aliases,
+		Help:     cmd.Help,
+		Group:    cmd.Group,
+		Default:  cmd.Default,
+		Options:  []jsonOption{},
+		Commands: []jsonCommand{},
+	}
+	for _, operand := range cmd.Operands {
+		c.Operands = append(c.Operands, jsonOperand{
+			Name:     operand.Name,
+			Required: operand.Required,
+			Variadic: operand.Variadic,
+			Min:      operand.Min,
+			Max:      operand.Max,
+			Type:     types[operand.Type],
+			Help:     operand.Help,
+		})
+	}
+	for _, option := range cmd.Options {
+		o := jsonOption{
+			Long:       option.Long,
+			Kind:       kinds[option.Kind],
+			ArgName:    option.ArgName,
+			Type:       types[option.Type],
+			Choices:    option.Choices,
+			Required:   option.Required,
+			Persistent: option.Persistent,
+			Prefix:     option.Prefix,
+			Complete:   hints[option.CompleteAs],
+			Default:    option.Default,
+			EnvVar:     option.EnvVar,
+			Help:       option.Help,
+			Group:      option.Group,
+			Deprecated: option.Deprecated,
+			Sensitive:  option.Sensitive,
+			Secret:     option.Secret,
+		}
+		if option.Kind == optparse.KindUntil {
+			o.Until = option.Until
+			if o.Until == "" {
+				o.Until = ";"
+			}
+		}
+		if option.Short != 0 {
+			o.Short = string(option.Short)
+		}
+		if option.Pattern != nil {
+			o.Pattern = option.Pattern.String()
+		}
+		if option.Min != 0 || option.Max != 0 {
+			o.Min, o.Max = bound(option.Min), bound(option.Max)
+		}
+		c.Options = append(c.Options, o)
+	}
+	for _, sub := range cmd.Commands {
+		c.Commands = append(c.Commands, jsonTree(sub))
+	}
+	return c
+}
+
+// bound returns a pointer to a finite bound, or nil, as JSON has no
+// representation for infinity.
+func bound(f float64) *float64 {
+	if math.IsInf(f, 0) {
+		return nil
+	}
+	return &f
+}
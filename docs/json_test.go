@@ -0,0 +1,93 @@
+package docs
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"reflect"
+	"regexp"
+	"testing"
+
+	"nullprogram.com/x/optparse"
+)
+
+func TestJSON(t *testing.T) {
+	root := &optparse.Command{
+		Name: "app",
+		Options: []optparse.Option{
+			{
+				Long:    "jobs",
+				Short:   'j',
+				Kind:    optparse.KindRequired,
+				Type:    optparse.TypeInt,
+				Min:     1,
+				Max:     math.Inf(1),
+				Pattern: regexp.MustCompile(`^\d+$`),
+				Help:    "parallel jobs",
+			},
+			{Long: "exec", Kind: optparse.KindUntil, Deprecated: "use --run"},
+			{Long: "run", Kind: optparse.KindUntil, Until: "+"},
+			{Long: "token", Kind: optparse.KindRequired, Sensitive: true},
+			{Long: "password", Kind: optparse.KindRequired, Secret: true},
+		},
+		Commands: []*optparse.Command{{
+			Name:    "log",
+			Aliases: []string{"l"},
+			Group:   "Basic",
+			Options: []optparse.Option{
+				{
+					Long:    "format",
+					Kind:    optparse.KindOptional,
+					Choices: []string{"short", "full"},
+					Default: "short",
+					EnvVar:  "APP_FORMAT",
+				},
+			},
+			Operands: []optparse.Operand{
+				{Name: "REV", Required: true, Help: "first revision"},
+				{Name: "PATH", Variadic: true, Max: 3},
+			},
+		}},
+	}
+
+	var buf bytes.Buffer
+	if err := JSON(&buf, root); err != nil {
+		t.Fatal(err)
+	}
+	var got any
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	var want any
+	json.Unmarshal([]byte(`{
+		"name": "app",
+		"options": [{
+			"long": "jobs", "short": "j", "kind": "required", "type": "int",
+			"pattern": "^\\d+$", "min": 1, "help": "parallel jobs"
+		}, {
+			"long": "exec", "kind": "until", "until": ";", "type": "string",
+			"deprecated": "use --run"
+		}, {
+			"long": "run", "kind": "until", "until": "+", "type": "string"
+		}, {
+			"long": "token", "kind": "required", "type": "string", "sensitive": true
+		}, {
+			"long": "password", "kind": "required", "type": "string", "secret": true
+		}],
+		"commands": [{
+			"name": "log", "aliases": ["l"], "group": "Basic",
+			"options": [{
+				"long": "format", "kind": "optional", "type": "string",
+				"choices": ["short", "full"], "default": "short", "env": "APP_FORMAT"
+			}],
+			"commands": [],
+			"operands": [
+				{"name": "REV", "required": true, "type": "string", "help": "first revision"},
+				{"name": "PATH", "variadic": true, "max": 3, "type": "string"}
+			]
+		}]
+	}`), &want)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("JSON(), got:\n%s", buf.String())
+	}
+}
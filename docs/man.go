@@ -0,0 +1,88 @@
+// This is free and unencumbered software released into the public domain.
+
+package docs
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"nullprogram.com/x/optparse"
+)
+
+// Man writes a section 1 man page in roff for the command, with NAME,
+// SYNOPSIS, and OPTIONS sections drawn from its Help and options, and a
+// COMMANDS section describing each subcommand and its options. For a
+// program without subcommands, cmd need only have Name and Options.
+func Man(w io.Writer, cmd *optparse.Command) error {
+	b := bufio.NewWriter(w)
+	fmt.Fprintf(b, ".TH %s 1\n", roff(strings.ToUpper(cmd.Name)))
+
+	fmt.Fprintf(b, ".SH NAME\n%s", roff(cmd.Name))
+	if cmd.Help != "" {
+		fmt.Fprintf(b, " \\- %s", roff(cmd.Help))
+	}
+	fmt.Fprintln(b)
+
+	fmt.Fprintf(b, ".SH SYNOPSIS\n.B %s\n", roff(cmd.Name))
+	fmt.Fprintf(b, "[\\fIOPTION\\fR]...%s\n", operands(cmd, func(name string) string {
+		return "\\fI" + roff(name) + "\\fR"
+	}))
+
+	if len(cmd.Options) > 0 {
+		fmt.Fprintln(b, ".SH OPTIONS")
+		manOptions(b, cmd.Options)
+	}
+
+	if len(cmd.Commands) > 0 {
+		fmt.Fprintln(b, ".SH COMMANDS")
+		for _, sub := range cmd.Commands {
+			walk(sub, []string{cmd.Name}, func(c *optparse.Command, path []string) {
+				fmt.Fprintf(b, ".SS %s\n", roff(strings.Join(path, " ")))
+				if c.Help != "" {
+					fmt.Fprintf(b, "%s\n", roff(c.Help))
+				}
+				manOptions(b, c.Options)
+			})
+		}
+	}
+	return b.Flush()
+}
+
+// manOptions writes a tagged paragraph for each option.
+func manOptions(b *bufio.Writer, options []optparse.Option) {
+	for _, option := range options {
+		var names []string
+		if option.Short != 0 {
+			names = append(names, "\\fB"+roff("-"+string(option.Short))+"\\fR")
+		}
+		if option.Long != "" {
+			names = append(names, "\\fB"+roff("--"+option.Long)+"\\fR")
+		}
+		fmt.Fprintf(b, ".TP\n%s%s\n", strings.Join(names, ", "),
+			argument(option, "\\fI", "\\fR", roff))
+		if help := describe(option); help != "" {
+			fmt.Fprintf(b, "%s\n", roff(help))
+		}
+	}
+}
+
+// roff escapes text for inclusion in a roff document.
+func roff(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '\\':
+			b.WriteString("\\e")
+		case c == '-':
+			b.WriteString("\\-")
+		case (c == '.' || c == '\'') && b.Len() == 0:
+			b.WriteString("\\&")
+			b.WriteByte(c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,105 @@
+package docs
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"nullprogram.com/x/optparse"
+)
+
+var command = &optparse.Command{
+	Name: "vcs",
+	Help: "track changes",
+	Options: []optparse.Option{
+		{Long: "verbose", Short: 'v', Kind: optparse.KindNone, Help: "explain what is done"},
+		{Long: "color", Kind: optparse.KindOptional, Help: "colorize: auto, always"},
+	},
+	Commands: []*optparse.Command{
+		{
+			Name: "commit",
+			Help: "record changes",
+			Options: []optparse.Option{
+				{Long: "message", Short: 'm', Kind: optparse.KindRequired, Help: ".use this message"},
+			},
+		},
+		{
+			Name: "remote",
+			Commands: []*optparse.Command{{
+				Name:     "add",
+				Help:     `add a remote \ URL`,
+				Operands: []optparse.Operand{{Name: "NAME", Required: true}, {Name: "URL"}},
+			}},
+		},
+	},
+}
+
+func TestMan(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Man(&buf, command); err != nil {
+		t.Fatal(err)
+	}
+	want := `.TH VCS 1
+.SH NAME
+vcs \- track changes
+.SH SYNOPSIS
+.B vcs
+[\fIOPTION\fR]... \fICOMMAND\fR [\fIARG\fR]...
+.SH OPTIONS
+.TP
+\fB\-v\fR, \fB\-\-verbose\fR
+explain what is done
+.TP
+\fB\-\-color\fR[=\fIARG\fR]
+colorize: auto, always
+.SH COMMANDS
+.SS vcs commit
+record changes
+.TP
+\fB\-m\fR, \fB\-\-message\fR=\fIARG\fR
+\&.use this message
+.SS vcs remote
+.SS vcs remote add
+add a remote \e URL
+`
+	if got := buf.String(); got != want {
+		t.Errorf("Man(), got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestManOperands(t *testing.T) {
+	cmd := &optparse.Command{
+		Name:     "cp",
+		Operands: []optparse.Operand{{Name: "SOURCE", Required: true}, {Name: "DEST-DIR", Variadic: true}},
+	}
+	var buf bytes.Buffer
+	if err := Man(&buf, cmd); err != nil {
+		t.Fatal(err)
+	}
+	want := ".SH SYNOPSIS\n.B cp\n[\\fIOPTION\\fR]... \\fISOURCE\\fR [\\fIDEST\\-DIR\\fR]...\n"
+	if got := buf.String(); !strings.Contains(got, want) {
+		t.Errorf("Man(), got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestManArgName(t *testing.T) {
+	cmd := &optparse.Command{
+		Name: "find",
+		Options: []optparse.Option{
+			{Long: "prune-dir", Kind: optparse.KindRequired, ArgName: `C:\DIR-NAME`},
+			{Long: "exec", Kind: optparse.KindUntil, ArgName: "CMD", Until: `\-`},
+		},
+	}
+	var buf bytes.Buffer
+	if err := Man(&buf, cmd); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"\\fB\\-\\-prune\\-dir\\fR=\\fIC:\\eDIR\\-NAME\\fR\n",
+		"\\fB\\-\\-exec\\fR \\fICMD\\fR... \\e\\-\n",
+	} {
+		if got := buf.String(); !strings.Contains(got, want) {
+			t.Errorf("Man(), got:\n%s\nwant:\n%s", got, want)
+		}
+	}
+}
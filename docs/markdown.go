@@ -0,0 +1,114 @@
+// This is free and unencumbered software released into the public domain.
+
+package docs
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"nullprogram.com/x/optparse"
+)
+
+// Markdown writes a Markdown reference page for the command alone,
+// with its usage, options, and a list of subcommands linking to their
+// pages as named by MarkdownFiles. For a program without subcommands,
+// cmd need only have Name and Options.
+func Markdown(w io.Writer, cmd *optparse.Command) error {
+	return markdown(w, cmd, []string{cmd.Name}, nil)
+}
+
+// MarkdownFiles writes a Markdown page for the command and each of its
+// descendants into dir, named by command path, such as
+// "vcs_remote_add.md". Pages for subcommands also list the persistent
+// options inherited from their ancestors.
+func MarkdownFiles(dir string, cmd *optparse.Command) error {
+	return markdownFiles(dir, cmd, nil, nil)
+}
+
+func markdownFiles(dir string, cmd *optparse.Command, path []string, inherited []optparse.Option) error {
+	path = append(path[:len(path):len(path)], cmd.Name)
+	f, err := os.Create(filepath.Join(dir, page(path)))
+	if err != nil {
+		return err
+	}
+	err = markdown(f, cmd, path, inherited)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, option := range cmd.Options {
+		if option.Persistent {
+			inherited = append(inherited[:len(inherited):len(inherited)], option)
+		}
+	}
+	for _, sub := range cmd.Commands {
+		if err := markdownFiles(dir, sub, path, inherited); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// page returns the file name of the page for a command path.
+func page(path []string) string {
+	return strings.Join(path, "_") + ".md"
+}
+
+func markdown(w io.Writer, cmd *optparse.Command, path []string, inherited []optparse.Option) error {
+	b := bufio.NewWriter(w)
+	name := strings.Join(path, " ")
+	fmt.Fprintf(b, "# %s\n\n", name)
+	if cmd.Help != "" {
+		fmt.Fprintf(b, "%s\n\n", cmd.Help)
+	}
+
+	fmt.Fprintf(b, "## Usage\n\n    %s [OPTION]...%s\n", name,
+		operands(cmd, func(name string) string { return name }))
+
+	if len(cmd.Options) > 0 {
+		fmt.Fprint(b, "\n## Options\n\n")
+		markdownOptions(b, cmd.Options)
+	}
+	if len(inherited) > 0 {
+		fmt.Fprint(b, "\n## Inherited Options\n\n")
+		markdownOptions(b, inherited)
+	}
+
+	if len(cmd.Commands) > 0 {
+		fmt.Fprint(b, "\n## Commands\n\n")
+		for _, sub := range cmd.Commands {
+			subpath := append(path[:len(path):len(path)], sub.Name)
+			fmt.Fprintf(b, "- [%s](%s)", sub.Name, page(subpath))
+			if sub.Help != "" {
+				fmt.Fprintf(b, ": %s", sub.Help)
+			}
+			fmt.Fprint(b, "\n")
+		}
+	}
+	return b.Flush()
+}
+
+// markdownOptions writes a list item for each option.
+func markdownOptions(b *bufio.Writer, options []optparse.Option) {
+	for _, option := range options {
+		var names []string
+		if option.Short != 0 {
+			names = append(names, "-"+string(option.Short))
+		}
+		if option.Long != "" {
+			names = append(names, "--"+option.Long)
+		}
+		fmt.Fprintf(b, "- `%s%s`", strings.Join(names, ", "), argument(option, "", "", func(s string) string { return s }))
+		if help := describe(option); help != "" {
+			fmt.Fprintf(b, ": %s", help)
+		}
+		fmt.Fprint(b, "\n")
+	}
+}
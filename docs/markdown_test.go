@@ -0,0 +1,58 @@
+package docs
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"nullprogram.com/x/optparse"
+)
+
+func TestMarkdown(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Markdown(&buf, command); err != nil {
+		t.Fatal(err)
+	}
+	want := "# vcs\n\ntrack changes\n\n" +
+		"## Usage\n\n    vcs [OPTION]... COMMAND [ARG]...\n\n" +
+		"## Options\n\n" +
+		"- `-v, --verbose`: explain what is done\n" +
+		"- `--color[=ARG]`: colorize: auto, always\n\n" +
+		"## Commands\n\n" +
+		"- [commit](vcs_commit.md): record changes\n" +
+		"- [remote](vcs_remote.md)\n"
+	if got := buf.String(); got != want {
+		t.Errorf("Markdown(), got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestMarkdownFiles(t *testing.T) {
+	root := &optparse.Command{
+		Name: "vcs",
+		Options: []optparse.Option{
+			{Long: "verbose", Short: 'v', Kind: optparse.KindNone, Persistent: true},
+		},
+		Commands: command.Commands,
+	}
+	dir := t.TempDir()
+	if err := MarkdownFiles(dir, root); err != nil {
+		t.Fatal(err)
+	}
+
+	names, _ := filepath.Glob(filepath.Join(dir, "*.md"))
+	if len(names) != 4 {
+		t.Errorf("MarkdownFiles(), got %q", names)
+	}
+	got, err := os.ReadFile(filepath.Join(dir, "vcs_remote_add.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "# vcs remote add\n\nadd a remote \\ URL\n\n" +
+		"## Usage\n\n    vcs remote add [OPTION]... NAME [URL]\n\n" +
+		"## Inherited Options\n\n" +
+		"- `-v, --verbose`\n"
+	if string(got) != want {
+		t.Errorf("MarkdownFiles(), got:\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -0,0 +1,129 @@
+// This is free and unencumbered software released into the public domain.
+
+package optparse
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// PrependEnvArgs returns a copy of args with the contents of the named
+// environment variable, split by SplitLine, inserted just after
+// args[0], allowing users to set persistent defaults in the style of
+// GREP_OPTIONS or JAVA_TOOL_OPTIONS. Since these arguments come first,
+// options given on the command line are seen later and may override
+// them. If the variable is unset or empty, args is returned unchanged.
+// Bad quoting in the variable is an error naming it.
+func PrependEnvArgs(args []string, name string) ([]string, error) {
+	fields, err := SplitLine(os.Getenv(name))
+	if err != nil {
+		return nil, fmt.Errorf("$%s: %w", name, err)
+	}
+	if len(fields) == 0 || len(args) == 0 {
+		return args, nil
+	}
+	result := make([]string, 0, len(args)+len(fields))
+	result = append(result, args[0])
+	result = append(result, fields...)
+	return append(result, args[1:]...), nil
+}
+
+// LoadDotenv reads a .env file of NAME=value lines and sets each
+// variable not already set in the environment, so that the real
+// environment wins, for Resolve and PrependEnvArgs to consult during
+// local development. An empty path selects .env in the working
+// directory, which need not exist. Lines may begin with "export", and
+// values may be quoted, with escapes like Go within double quotes, or
+// followed by a # comment. Syntax errors are ConfigErrors.
+func LoadDotenv(path string) error {
+	name := path
+	if name == "" {
+		name = ".env"
+	}
+	f, err := os.Open(name)
+	if errors.Is(err, os.ErrNotExist) && path == "" {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	vars, err := readDotenv(f)
+	if cerr, ok := err.(ConfigError); ok {
+		cerr.File = name
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	for _, v := range vars {
+		if _, ok := os.LookupEnv(v[0]); !ok {
+			if err := os.Setenv(v[0], v[1]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// readDotenv returns the name and value of each variable in a .env
+// file, in order.
+func readDotenv(r io.Reader) ([][2]string, error) {
+	var vars [][2]string
+	s := bufio.NewScanner(r)
+	for line := 1; s.Scan(); line++ {
+		fail := func(err error) ([][2]string, error) {
+			return nil, ConfigError{Line: line, Err: err}
+		}
+		text := strings.TrimSpace(s.Text())
+		if comment(text) {
+			continue
+		}
+		text = strings.TrimPrefix(text, "export ")
+		name, value, ok := strings.Cut(text, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" || strings.ContainsAny(name, " \t") {
+			return fail(fmt.Errorf("expected NAME=value, got %q", text))
+		}
+
+		value = strings.TrimSpace(value)
+		rest := ""
+		switch {
+		case strings.HasPrefix(value, `"`):
+			end := 1
+			for ; end < len(value) && value[end] != '"'; end++ {
+				if value[end] == '\\' {
+					end++
+				}
+			}
+			if end >= len(value) {
+				return fail(fmt.Errorf("unterminated string %s", value))
+			}
+			unquoted, err := strconv.Unquote(value[:end+1])
+			if err != nil {
+				return fail(fmt.Errorf("invalid string %s", value[:end+1]))
+			}
+			value, rest = unquoted, value[end+1:]
+		case strings.HasPrefix(value, "'"):
+			end := strings.IndexByte(value[1:], '\'')
+			if end < 0 {
+				return fail(fmt.Errorf("unterminated string %s", value))
+			}
+			value, rest = value[1:end+1], value[end+2:]
+		default:
+			if i := strings.Index(value, " #"); i >= 0 {
+				value = strings.TrimSpace(value[:i])
+			}
+		}
+		if rest = strings.TrimSpace(rest); !comment(rest) {
+			return fail(fmt.Errorf("unexpected %q", rest))
+		}
+		vars = append(vars, [2]string{name, value})
+	}
+	return vars, s.Err()
+}
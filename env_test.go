@@ -0,0 +1,109 @@
+package optparse
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPrependEnvArgs(t *testing.T) {
+	const name = "OPTPARSE_TEST_OPTS"
+
+	table := []struct {
+		env  string
+		args []string
+		want []string
+		err  string
+	}{
+		{"", []string{"", "-a"}, []string{"", "-a"}, ""},
+		{"-b  -d 10", []string{"", "-a"}, []string{"", "-b", "-d", "10", "-a"}, ""},
+		{"\t-e\n", []string{"prog"}, []string{"prog", "-e"}, ""},
+		{"-e", []string{}, []string{}, ""},
+		{`-m 'two words' -x\ y`, []string{"", "-a"}, []string{"", "-m", "two words", "-x y", "-a"}, ""},
+		{`-m "open`, []string{"", "-a"}, nil, "$" + name + ": unterminated quote"},
+	}
+
+	for _, row := range table {
+		t.Setenv(name, row.env)
+		got, err := PrependEnvArgs(row.args, name)
+		if (err == nil && row.err != "") || (err != nil && err.Error() != row.err) {
+			t.Errorf("PrependEnvArgs(%q) with %q, got error %v, want %q",
+				row.args, row.env, err, row.err)
+		}
+		if !equal(got, row.want) {
+			t.Errorf("PrependEnvArgs(%q) with %q, got %q, want %q",
+				row.args, row.env, got, row.want)
+		}
+	}
+}
+
+func TestLoadDotenv(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "test.env")
+	content := `# comment
+OPTPARSE_TEST_A=plain value # comment
+export OPTPARSE_TEST_B = "tab\there" # comment
+OPTPARSE_TEST_C='lit\eral'
+OPTPARSE_TEST_D=
+OPTPARSE_TEST_SET=from file
+`
+	if err := os.WriteFile(path, []byte(content), 0o666); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"A", "B", "C", "D"} {
+		t.Setenv("OPTPARSE_TEST_"+name, "") // restored after the test
+		os.Unsetenv("OPTPARSE_TEST_" + name)
+	}
+	t.Setenv("OPTPARSE_TEST_SET", "real")
+
+	if err := LoadDotenv(path); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"A":   "plain value",
+		"B":   "tab\there",
+		"C":   `lit\eral`,
+		"D":   "",
+		"SET": "real",
+	}
+	for name, value := range want {
+		got, ok := os.LookupEnv("OPTPARSE_TEST_" + name)
+		if !ok || got != value {
+			t.Errorf("LoadDotenv(), got %s=%q %v, want %q", name, got, ok, value)
+		}
+	}
+
+	errs := []struct {
+		content, msg string
+	}{
+		{"\nNO VALUE\n", `:2: expected NAME=value, got "NO VALUE"`},
+		{`A="open`, `:1: unterminated string "open`},
+		{`A='x' y`, `:1: unexpected "y"`},
+		{`A="\q"`, `:1: invalid string "\q"`},
+	}
+	for _, test := range errs {
+		if err := os.WriteFile(path, []byte(test.content), 0o666); err != nil {
+			t.Fatal(err)
+		}
+		err := LoadDotenv(path)
+		if want := path + test.msg; err == nil || err.Error() != want {
+			t.Errorf("LoadDotenv(%q), got %v, want %q", test.content, err, want)
+		}
+	}
+
+	if err := LoadDotenv(filepath.Join(dir, "missing")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("LoadDotenv(), got %v, want %v", err, os.ErrNotExist)
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	if err := LoadDotenv(""); err != nil {
+		t.Errorf("LoadDotenv(), got %v, want nil for missing .env", err)
+	}
+}
@@ -0,0 +1,104 @@
+// This is free and unencumbered software released into the public domain.
+
+package optparse
+
+import (
+	"flag"
+	"unicode/utf8"
+)
+
+// ParseFlagSet parses args with getopt-style syntax into the flags
+// already defined in a standard library FlagSet. Flags with single
+// character names become short options and all others become long
+// options. Other flags require an argument. Boolean flags take none,
+// except that a long one accepts an attached value, as in
+// "--verbose=false", like fs.Parse. A short one cannot, as "-v=false"
+// would be a bundle.
+// Values are assigned with fs.Set, so fs.Visit, fs.Parsed, and fs.Args
+// behave as if fs.Parse had been called.
+//
+// Like Parse, and unlike fs.Parse, args[0] is the program name and is
+// skipped. It returns the remaining arguments.
+func ParseFlagSet(fs *flag.FlagSet, args []string) ([]string, error) {
+	var options []Option
+	fs.VisitAll(func(f *flag.Flag) {
+		name := f.Name
+		option := Option{
+			Kind: KindRequired,
+			Help: f.Usage,
+			Func: func(optarg string) error { return fs.Set(name, optarg) },
+		}
+		if utf8.RuneCountInString(name) == 1 {
+			option.Short, _ = utf8.DecodeRuneInString(name)
+		} else {
+			option.Long = name
+		}
+		if b, ok := f.Value.(interface{ IsBoolFlag() bool }); ok && b.IsBoolFlag() {
+			option.Kind = KindNone
+			option.Func = func(string) error { return fs.Set(name, "true") }
+			if option.Long != "" {
+				option.Kind = KindOptional
+				option.Func = func(optarg string) error {
+					if optarg == "" {
+						optarg = "true"
+					}
+					return fs.Set(name, optarg)
+				}
+			}
+		}
+		options = append(options, option)
+	})
+
+	rest, err := Run(options, args)
+	if err != nil {
+		return rest, err
+	}
+	// Mark the FlagSet as parsed with the remaining arguments.
+	return rest, fs.Parse(append([]string{"--"}, rest...))
+}
+
+// Lookup returns a standard library view of the last occurrence of the
+// named option, or nil if it was not given, mirroring FlagSet.Lookup.
+// The flag's Value implements flag.Getter: Get returns the converted
+// Result.Value when the option has a Type, true for options without an
+// argument, and otherwise the argument string.
+func (r Results) Lookup(name string) *flag.Flag {
+	for i := len(r) - 1; i >= 0; i-- {
+		result := r[i]
+		if !result.is(name) {
+			continue
+		}
+		value := &getter{s: result.Optarg, v: result.Value}
+		switch {
+		case result.Kind == KindNone:
+			value.s, value.v = "true", true
+		case value.v == nil:
+			value.v = result.Optarg
+		}
+		return &flag.Flag{
+			Name:  result.name(),
+			Usage: result.Help,
+			Value: value,
+		}
+	}
+	return nil
+}
+
+// getter is a flag.Getter holding a parsed option argument.
+type getter struct {
+	s string
+	v any
+}
+
+func (g *getter) String() string {
+	return g.s
+}
+
+func (g *getter) Set(s string) error {
+	g.s, g.v = s, s
+	return nil
+}
+
+func (g *getter) Get() any {
+	return g.v
+}
@@ -0,0 +1,91 @@
+package optparse
+
+import (
+	"flag"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseFlagSet(t *testing.T) {
+	fs := flag.NewFlagSet("prog", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	verbose := fs.Bool("verbose", false, "print more")
+	short := fs.Bool("q", false, "quiet")
+	jobs := fs.Int("jobs", 1, "parallel jobs")
+	name := fs.String("n", "", "name")
+	timeout := fs.Duration("timeout", time.Second, "timeout")
+
+	args := []string{"prog", "--verbose", "-qnfoo", "--jobs=4", "--timeout", "1m", "x", "-y"}
+	rest, err := ParseFlagSet(fs, args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !*verbose || !*short || *jobs != 4 || *name != "foo" || *timeout != time.Minute {
+		t.Errorf("ParseFlagSet(), got %v %v %v %q %v", *verbose, *short, *jobs, *name, *timeout)
+	}
+	if !equal(rest, []string{"x", "-y"}) || !equal(fs.Args(), rest) || !fs.Parsed() {
+		t.Errorf("ParseFlagSet(), got %q %q %v", rest, fs.Args(), fs.Parsed())
+	}
+	var set []string
+	fs.Visit(func(f *flag.Flag) { set = append(set, f.Name) })
+	if want := []string{"jobs", "n", "q", "timeout", "verbose"}; !equal(set, want) {
+		t.Errorf("Visit(), got %q, want %q", set, want)
+	}
+
+	rest, err = ParseFlagSet(fs, []string{"prog", "--verbose=false", "-q", "x"})
+	if err != nil || *verbose || !equal(rest, []string{"x"}) {
+		t.Errorf("ParseFlagSet(), got %v %v %q", *verbose, err, rest)
+	}
+	_, err = ParseFlagSet(fs, []string{"prog", "--verbose=maybe"})
+	if err == nil || !strings.Contains(err.Error(), "--verbose") {
+		t.Errorf("ParseFlagSet(), got %v, want error naming --verbose", err)
+	}
+
+	_, err = ParseFlagSet(fs, []string{"prog", "--jobs", "many"})
+	if err == nil || !strings.Contains(err.Error(), "--jobs") {
+		t.Errorf("ParseFlagSet(), got %v, want error naming --jobs", err)
+	}
+}
+
+func TestResultsLookup(t *testing.T) {
+	options := []Option{
+		{Long: "verbose", Short: 'v', Kind: KindNone, Help: "print more"},
+		{Long: "jobs", Short: 'j', Kind: KindRequired, Type: TypeInt},
+		{Short: 'n', Kind: KindRequired},
+	}
+	results, _, err := Parse(options, []string{"", "-v", "-j2", "-j3", "-nfoo"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	table := []struct {
+		name string
+		s    string
+		v    any
+	}{
+		{"verbose", "true", true},
+		{"j", "3", 3},
+		{"n", "foo", "foo"},
+	}
+	for _, row := range table {
+		f := results.Lookup(row.name)
+		if f == nil {
+			t.Errorf("Lookup(%q), got nil", row.name)
+			continue
+		}
+		g := f.Value.(flag.Getter)
+		if g.String() != row.s || g.Get() != row.v {
+			t.Errorf("Lookup(%q), got %q %#v, want %q %#v",
+				row.name, g.String(), g.Get(), row.s, row.v)
+		}
+	}
+
+	if f := results.Lookup("v"); f.Name != "verbose" || f.Usage != "print more" {
+		t.Errorf(`Lookup("v"), got %q %q`, f.Name, f.Usage)
+	}
+	if f := results.Lookup("missing"); f != nil {
+		t.Errorf(`Lookup("missing"), got %v, want nil`, f)
+	}
+}
@@ -0,0 +1,31 @@
+// This is free and unencumbered software released into the public domain.
+
+package optparse
+
+import (
+	"fmt"
+	"strings"
+)
+
+// GNUFormat returns a Config.Format function reproducing the exact
+// error messages of glibc's getopt_long(), prefixed by the given program
+// name, typically os.Args[0]. Errors without a glibc equivalent use the
+// default text, also prefixed by the program name.
+func GNUFormat(prog string) func(Error) string {
+	return func(e Error) string {
+		long := strings.HasPrefix(e.Arg, "--")
+		switch {
+		case e.Err == ErrInvalid && long:
+			return fmt.Sprintf("%s: unrecognized option '%s'", prog, e.Arg)
+		case e.Err == ErrInvalid:
+			return fmt.Sprintf("%s: invalid option -- '%c'", prog, e.Short)
+		case e.Err == ErrMissing && long:
+			return fmt.Sprintf("%s: option '--%s' requires an argument", prog, e.Long)
+		case e.Err == ErrMissing:
+			return fmt.Sprintf("%s: option requires an argument -- '%c'", prog, e.Short)
+		case e.Err == ErrTooMany:
+			return fmt.Sprintf("%s: option '--%s' doesn't allow an argument", prog, e.Long)
+		}
+		return fmt.Sprintf("%s: %s", prog, e.Error())
+	}
+}
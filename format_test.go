@@ -0,0 +1,27 @@
+package optparse
+
+import (
+	"testing"
+)
+
+func TestGNUFormat(t *testing.T) {
+	table := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"", "-x"}, "prog: invalid option -- 'x'"},
+		{[]string{"", "-ax"}, "prog: invalid option -- 'x'"},
+		{[]string{"", "--foo=bar"}, "prog: unrecognized option '--foo=bar'"},
+		{[]string{"", "-d"}, "prog: option requires an argument -- 'd'"},
+		{[]string{"", "--delay"}, "prog: option '--delay' requires an argument"},
+		{[]string{"", "--amend=1"}, "prog: option '--amend' doesn't allow an argument"},
+	}
+
+	cfg := Config{Format: GNUFormat("prog")}
+	for _, row := range table {
+		_, _, err := ParseWith(cfg, options, row.args)
+		if err == nil || err.Error() != row.want {
+			t.Errorf("ParseWith(%q), got %v, want %q", row.args[1:], err, row.want)
+		}
+	}
+}
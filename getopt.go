@@ -0,0 +1,111 @@
+// This is free and unencumbered software released into the public domain.
+
+package optparse
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Getopt is a stateful interface mimicking the C library's getopt(3),
+// for porting C programs line by line. The zero value is ready to use.
+//
+//	var g optparse.Getopt
+//	for {
+//		c, _ := g.Getopt(os.Args, "ab:c::")
+//		if c == -1 {
+//			break
+//		}
+//		switch c {
+//		case 'a':
+//		case 'b':
+//			use(g.Optarg)
+//		case '?':
+//			os.Exit(1)
+//		}
+//	}
+//	operands := os.Args[g.Optind:]
+type Getopt struct {
+	// Optarg is the argument of the most recent option, if any.
+	Optarg string
+
+	// Optind is the index of the next argument to process. Setting it
+	// to 0 or 1 restarts parsing.
+	Optind int
+
+	// Optopt is the option character of the most recent error.
+	Optopt rune
+
+	// Opterr, if true, prints diagnostics to standard error in the
+	// same format as glibc, unless optstring begins with a colon. Unlike
+	// C's opterr, it is false by default.
+	Opterr bool
+
+	subopt int
+	last   int
+}
+
+// Getopt returns the next option character from args, as described by
+// optstring, or -1 when no options remain. In optstring, a character
+// followed by a colon requires an argument, and one followed by two
+// colons takes an optional argument. On error it returns '?', or ':' for
+// a missing argument when optstring begins with a colon, following any
+// + or -, along with an error describing the problem.
+func (g *Getopt) Getopt(args []string, optstring string) (rune, error) {
+	colon := strings.HasPrefix(strings.TrimLeft(optstring, "+-"), ":")
+	p := Parser{
+		options: compileOptstring(optstring),
+		args:    args,
+		optind:  g.Optind,
+		subopt:  g.subopt,
+	}
+	if g.Optind != g.last || g.Optind < 1 {
+		p.subopt = 0 // externally reset
+	}
+
+	result, ok, err := p.next()
+	g.Optarg = result.Optarg
+	if err != nil {
+		var e Error
+		errors.As(err, &e)
+		g.Optopt = e.Short
+		p.skip(err)
+		if g.Opterr && !colon && len(args) > 0 {
+			fmt.Fprintln(stderr, GNUFormat(args[0])(e))
+		}
+	}
+	g.Optind, g.subopt, g.last = p.optind, p.subopt, p.optind
+
+	switch {
+	case err == nil && !ok:
+		return -1, nil
+	case err == nil:
+		return result.Short, nil
+	case colon && errors.Is(err, ErrMissing):
+		return ':', err
+	default:
+		return '?', err
+	}
+}
+
+// compileOptstring converts a getopt(3) option string to an option
+// table.
+func compileOptstring(optstring string) []Option {
+	optstring = strings.TrimLeft(optstring, "+-:")
+	runes := []rune(optstring)
+	var options []Option
+	for i := 0; i < len(runes); i++ {
+		option := Option{Short: runes[i], Kind: KindNone}
+		if i+1 < len(runes) && runes[i+1] == ':' {
+			option.Kind = KindRequired
+			i++
+			if i+1 < len(runes) && runes[i+1] == ':' {
+				option.Kind = KindOptional
+				i++
+			}
+		}
+		options = append(options, option)
+	}
+	return options
+}
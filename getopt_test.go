@@ -0,0 +1,74 @@
+package optparse
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestGetopt(t *testing.T) {
+	args := []string{"prog", "-ab", "foo", "-cbar", "-c", "-x", "-πd", "--", "-a", "x"}
+	want := []struct {
+		c      rune
+		optarg string
+	}{
+		{'a', ""},
+		{'b', "foo"},
+		{'c', "bar"},
+		{'c', ""},
+		{'?', ""},
+		{'π', ""},
+		{'?', ""},
+		{-1, ""},
+	}
+
+	var buf bytes.Buffer
+	old := stderr
+	stderr = &buf
+	defer func() { stderr = old }()
+
+	g := Getopt{Opterr: true}
+	for i, w := range want {
+		c, err := g.Getopt(args, "ab:c::π")
+		if c != w.c || g.Optarg != w.optarg || (c == '?') != (err != nil) {
+			t.Errorf("Getopt() #%d, got %q %q %v, want %q %q",
+				i, c, g.Optarg, err, w.c, w.optarg)
+		}
+	}
+	if rest := args[g.Optind:]; !equal(rest, []string{"-a", "x"}) {
+		t.Errorf("Getopt(), got operands %q", rest)
+	}
+	if g.Optopt != 'd' {
+		t.Errorf("Getopt(), got Optopt %q, want 'd'", g.Optopt)
+	}
+	wantErr := "prog: invalid option -- 'x'\nprog: invalid option -- 'd'\n"
+	if buf.String() != wantErr {
+		t.Errorf("Getopt(), got %q, want %q", buf.String(), wantErr)
+	}
+
+	buf.Reset()
+	for _, optstring := range []string{":b:", "+:b:"} {
+		g = Getopt{Opterr: true}
+		c, err := g.Getopt([]string{"prog", "-b"}, optstring)
+		if c != ':' || !errors.Is(err, ErrMissing) || g.Optopt != 'b' {
+			t.Errorf("Getopt(%q), got %q %v, want ':'", optstring, c, err)
+		}
+		g = Getopt{Opterr: true}
+		c, err = g.Getopt([]string{"prog", "-x"}, optstring)
+		if c != '?' || !errors.Is(err, ErrInvalid) {
+			t.Errorf("Getopt(%q), got %q %v, want '?'", optstring, c, err)
+		}
+	}
+	if buf.Len() != 0 {
+		t.Errorf("Getopt(), got diagnostics %q despite colon", buf.String())
+	}
+
+	var c rune
+
+	// restart
+	g.Optind = 1
+	c, _ = g.Getopt([]string{"prog", "-b1"}, "b:")
+	if c != 'b' || g.Optarg != "1" || g.Optind != 2 {
+		t.Errorf("Getopt(), got %q %q %d after restart", c, g.Optarg, g.Optind)
+	}
+}
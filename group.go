@@ -0,0 +1,108 @@
+// This is free and unencumbered software released into the public domain.
+
+package optparse
+
+// ResultGroup is the options and operands between Separator options.
+type ResultGroup struct {
+	Results  Results
+	Operands []string
+}
+
+// ParseGroups parses args like Parse, but divides the results into
+// groups at each Separator option, for commands such as curl that handle
+// independent blocks of options. The separators themselves are omitted.
+// Unlike Parse, options may follow operands within a group, up to a
+// "--", after which the remaining arguments are operands of the last
+// group. Parsing stops at the first error, returning the groups so far.
+// Required options are not checked.
+func ParseGroups(options []Option, args []string) ([]ResultGroup, error) {
+	p := NewParser(options, args)
+	groups := []ResultGroup{{}}
+	err := p.walk(func(result Result) {
+		if result.Separator {
+			groups = append(groups, ResultGroup{})
+			return
+		}
+		group := &groups[len(groups)-1]
+		group.Results = append(group.Results, result)
+	}, func(i int) {
+		group := &groups[len(groups)-1]
+		group.Operands = append(group.Operands, p.args[i])
+	})
+	return groups, err
+}
+
+// Binding is an enumeration of the operands to which ParseOperandGroups
+// binds options.
+type Binding int
+
+const (
+	// BindFollowing binds options to the following operand, such as the
+	// output options of ffmpeg.
+	BindFollowing Binding = iota
+	// BindPreceding binds options to the preceding operand.
+	BindPreceding
+)
+
+// OperandGroup is an operand with the options bound to it. Index is the
+// position of Operand in args, or -1 for options bound to no operand.
+type OperandGroup struct {
+	Operand string
+	Index   int
+	Results Results
+}
+
+// ParseOperandGroups parses args like ParseGroups, but binds options to
+// an operand, such as "-map 0 out1.mkv -map 1 out2.mkv", returning a
+// group for each operand in order. Options not bound to any operand,
+// following the last with BindFollowing or preceding the first with
+// BindPreceding, form an additional group with an Index of -1, last or
+// first respectively.
+func ParseOperandGroups(options []Option, args []string, binding Binding) ([]OperandGroup, error) {
+	p := NewParser(options, args)
+	var groups []OperandGroup
+	var pending Results
+	if binding == BindPreceding {
+		groups = append(groups, OperandGroup{Index: -1})
+	}
+	err := p.walk(func(result Result) {
+		if binding == BindPreceding {
+			group := &groups[len(groups)-1]
+			group.Results = append(group.Results, result)
+		} else {
+			pending = append(pending, result)
+		}
+	}, func(i int) {
+		groups = append(groups, OperandGroup{p.args[i], i, pending})
+		pending = nil
+	})
+	if binding == BindPreceding && groups[0].Results == nil {
+		groups = groups[1:]
+	} else if pending != nil {
+		groups = append(groups, OperandGroup{Index: -1, Results: pending})
+	}
+	return groups, err
+}
+
+// walk parses options wherever they appear up to a "--", passing each
+// result and the index of each operand to the functions in order, and
+// stops at the first error.
+func (p *Parser) walk(result func(Result), operand func(int)) error {
+	for {
+		r, ok, err := p.Next()
+		switch {
+		case err != nil:
+			return err
+		case ok:
+			result(r)
+		case p.terminated || p.Index() == len(p.args):
+			for i := p.Index(); i < len(p.args); i++ {
+				operand(i)
+			}
+			return nil
+		default:
+			operand(p.Index())
+			p.optind, p.done = p.Index()+1, false
+		}
+	}
+}
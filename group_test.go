@@ -0,0 +1,75 @@
+package optparse
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestParseGroups(t *testing.T) {
+	options := []Option{
+		{Long: "header", Short: 'H', Kind: KindRequired},
+		{Long: "verbose", Short: 'v', Kind: KindNone},
+		{Long: "next", Short: ':', Kind: KindNone, Separator: true},
+	}
+	table := []struct {
+		args []string
+		want string
+		err  error
+	}{
+		{[]string{""}, "[{[] []}]", nil},
+		{
+			[]string{"", "-v", "-H", "a", "url1", "-Hb", "--next", "url2", "-:", "--", "-url3"},
+			"[{[--verbose --header=a --header=b] [url1]} {[] [url2]} {[] [-url3]}]",
+			nil,
+		},
+		{[]string{"", "--next", "-:"}, "[{[] []} {[] []} {[] []}]", nil},
+		{[]string{"", "a", "--next", "-x"}, "[{[] [a]} {[] []}]", ErrInvalid},
+	}
+	for _, row := range table {
+		groups, err := ParseGroups(options, row.args)
+		if !errors.Is(err, row.err) {
+			t.Errorf("ParseGroups(%q), got error %v, want %v", row.args, err, row.err)
+		}
+		if got := fmt.Sprint(groups); got != row.want {
+			t.Errorf("ParseGroups(%q), got %s, want %s", row.args, got, row.want)
+		}
+	}
+}
+
+func TestParseOperandGroups(t *testing.T) {
+	options := []Option{
+		{Long: "map", Short: 'm', Kind: KindRequired},
+		{Short: 'y', Kind: KindNone},
+	}
+	table := []struct {
+		args    []string
+		binding Binding
+		want    string
+	}{
+		{[]string{""}, BindFollowing, "[]"},
+		{[]string{""}, BindPreceding, "[]"},
+		{
+			[]string{"", "-m", "0", "out1.mkv", "-m1", "out2.mkv"}, BindFollowing,
+			"[{out1.mkv 3 [--map=0]} {out2.mkv 5 [--map=1]}]",
+		},
+		{
+			[]string{"", "a", "b", "-y", "--", "-c"}, BindFollowing,
+			"[{a 1 []} {b 2 []} {-c 5 [-y]}]",
+		},
+		{[]string{"", "a", "-y"}, BindFollowing, "[{a 1 []} { -1 [-y]}]"},
+		{
+			[]string{"", "-y", "in.mkv", "-m0", "-m1", "x"}, BindPreceding,
+			"[{ -1 [-y]} {in.mkv 2 [--map=0 --map=1]} {x 5 []}]",
+		},
+	}
+	for _, row := range table {
+		groups, err := ParseOperandGroups(options, row.args, row.binding)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := fmt.Sprint(groups); got != row.want {
+			t.Errorf("ParseOperandGroups(%q, %d), got %s, want %s", row.args, row.binding, got, row.want)
+		}
+	}
+}
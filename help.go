@@ -0,0 +1,81 @@
+// This is free and unencumbered software released into the public domain.
+
+package optparse
+
+import "slices"
+
+// HelpData is the data model of help text, given to Style.Template in
+// place of the built-in layout.
+type HelpData struct {
+	Name     string // empty for a bare option table
+	Help     string
+	Options  []OptionGroup
+	Commands []CommandGroup
+	Operands []Operand
+}
+
+// OptionGroup is a section of help listing options, by Option.Group.
+// The Title of the section of ungrouped options is empty.
+type OptionGroup struct {
+	Title   string
+	Options []HelpOption
+}
+
+// HelpOption is an option along with its synopsis in help, such as
+// "-d, --delay=ARG".
+type HelpOption struct {
+	Option
+	Synopsis string
+}
+
+// CommandGroup is a section of help listing subcommands, by
+// Command.Group. The Title of the section of ungrouped subcommands is
+// empty.
+type CommandGroup struct {
+	Title    string
+	Commands []*Command
+}
+
+// NewHelpData returns the data model of the command's help text. Groups
+// appear in order of first appearance, with any ungrouped section
+// first.
+func NewHelpData(c *Command) HelpData {
+	data := HelpData{Name: c.Name, Help: c.Help, Operands: c.Operands}
+
+	titles := []string{""}
+	for _, option := range c.Options {
+		if !slices.Contains(titles, option.Group) {
+			titles = append(titles, option.Group)
+		}
+	}
+	for _, title := range titles {
+		group := OptionGroup{Title: title}
+		for _, option := range c.Options {
+			if option.Group == title {
+				group.Options = append(group.Options, HelpOption{option, synopsis(option)})
+			}
+		}
+		if group.Options != nil {
+			data.Options = append(data.Options, group)
+		}
+	}
+
+	titles = []string{""}
+	for _, sub := range c.Commands {
+		if !slices.Contains(titles, sub.Group) {
+			titles = append(titles, sub.Group)
+		}
+	}
+	for _, title := range titles {
+		group := CommandGroup{Title: title}
+		for _, sub := range c.Commands {
+			if sub.Group == title {
+				group.Commands = append(group.Commands, sub)
+			}
+		}
+		if group.Commands != nil {
+			data.Commands = append(data.Commands, group)
+		}
+	}
+	return data
+}
@@ -0,0 +1,61 @@
+package optparse
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"text/template"
+)
+
+func TestNewHelpData(t *testing.T) {
+	root := &Command{
+		Name: "app",
+		Help: "do things",
+		Options: []Option{
+			{Long: "output", Short: 'o', Kind: KindRequired, Group: "Output"},
+			{Long: "help", Kind: KindNone},
+		},
+		Commands: []*Command{
+			{Name: "gc", Group: "Advanced"},
+			{Name: "init"},
+		},
+	}
+	data := NewHelpData(root)
+	want := HelpData{
+		Name: "app",
+		Help: "do things",
+		Options: []OptionGroup{
+			{"", []HelpOption{{root.Options[1], "    --help"}}},
+			{"Output", []HelpOption{{root.Options[0], "-o, --output=ARG"}}},
+		},
+		Commands: []CommandGroup{
+			{"", []*Command{root.Commands[1]}},
+			{"Advanced", []*Command{root.Commands[0]}},
+		},
+	}
+	if !reflect.DeepEqual(data, want) {
+		t.Errorf("NewHelpData(), got %+v, want %+v", data, want)
+	}
+}
+
+func TestStyleTemplate(t *testing.T) {
+	tmpl := template.Must(template.New("help").Parse(
+		`NAME {{.Name}} - {{.Help}}
+{{range .Options}}{{range .Options}}{{.Synopsis}}: {{.Help}}
+{{end}}{{end}}EXAMPLES
+  {{.Name}} -a
+`))
+	root := &Command{
+		Name:    "app",
+		Help:    "do things",
+		Options: []Option{{Long: "amend", Short: 'a', Kind: KindNone, Help: "amend"}},
+	}
+	var buf bytes.Buffer
+	if err := (Style{Template: tmpl}).CommandUsage(&buf, root); err != nil {
+		t.Fatal(err)
+	}
+	want := "NAME app - do things\n-a, --amend: amend\nEXAMPLES\n  app -a\n"
+	if got := buf.String(); got != want {
+		t.Errorf("CommandUsage(), got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,67 @@
+// This is free and unencumbered software released into the public domain.
+
+package optparse
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ParseINI reads an INI-style config file whose keys are long option
+// names, returning the results as though the options had been given on
+// the command line, ready for Results.Merge:
+//
+//	; comment
+//	color = always
+//	verbose
+//	[net]
+//	delay = 10
+//
+// A key without a value is like the option given without an argument,
+// and options without an argument also accept a boolean value. A key
+// within a section names the option "section.key", like git-config, so
+// the final line above sets --net.delay. Values may be quoted with
+// double or single quotes to preserve surrounding spaces. Each result's
+// Index is its line number. Errors are ConfigErrors.
+func ParseINI(r io.Reader, options []Option) (Results, error) {
+	return parseConfig(readINI, r, options)
+}
+
+// readINI reads the entries of an INI file up to the first error.
+func readINI(r io.Reader) ([]entry, error) {
+	var entries []entry
+	var section []string
+	s := bufio.NewScanner(r)
+	for line := 1; s.Scan(); line++ {
+		text := strings.TrimSpace(s.Text())
+		switch {
+		case text == "" || text[0] == ';' || text[0] == '#':
+			continue
+		case text[0] == '[':
+			if !strings.HasSuffix(text, "]") {
+				return entries, ConfigError{Line: line, Err: fmt.Errorf("invalid section %q", text)}
+			}
+			section = nil
+			if name := strings.TrimSpace(text[1 : len(text)-1]); name != "" {
+				section = []string{name}
+			}
+			continue
+		}
+
+		key, value, set := strings.Cut(text, "=")
+		path := append(section[:len(section):len(section)], strings.TrimSpace(key))
+		value = unquote(strings.TrimSpace(value))
+		entries = append(entries, entry{path, value, set, line})
+	}
+	return entries, s.Err()
+}
+
+// unquote removes matching double or single quotes around s.
+func unquote(s string) string {
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
@@ -0,0 +1,83 @@
+package optparse
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseINI(t *testing.T) {
+	options := []Option{
+		{Long: "color", Kind: KindOptional},
+		{Long: "verbose", Short: 'v', Kind: KindNone},
+		{Long: "quiet", Kind: KindNone},
+		{Long: "jobs", Kind: KindRequired, Type: TypeInt},
+		{Long: "net.delay", Kind: KindRequired},
+		{Long: "define-", Kind: KindRequired, Prefix: true},
+	}
+	input := `; comment
+# another
+color = always
+verbose
+quiet = off
+
+jobs=4
+[net]
+delay = " 10 "
+[]
+define-x = 'y'
+`
+	results, err := ParseINI(strings.NewReader(input), options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct {
+		long, optarg string
+		line         int
+	}{
+		{"color", "always", 3},
+		{"verbose", "", 4},
+		{"jobs", "4", 7},
+		{"net.delay", " 10 ", 9},
+		{"define-x", "y", 11},
+	}
+	if len(results) != len(want) {
+		t.Fatalf("ParseINI(), got %d results, want %d", len(results), len(want))
+	}
+	for i, w := range want {
+		r := results[i]
+		if r.Long != w.long || r.Optarg != w.optarg || r.Index != w.line {
+			t.Errorf("ParseINI()[%d], got %q %q %d, want %q %q %d",
+				i, r.Long, r.Optarg, r.Index, w.long, w.optarg, w.line)
+		}
+	}
+	if results[2].Value != 4 {
+		t.Errorf("ParseINI(), got Value %v, want 4", results[2].Value)
+	}
+
+	errs := []struct {
+		input string
+		err   error
+		msg   string
+	}{
+		{"\nbogus = 1\n", ErrInvalid, "line 2: invalid option: --bogus"},
+		{"jobs\n", ErrMissing, "line 1: option requires an argument: --jobs"},
+		{"verbose = maybe\n", nil, `line 1: invalid boolean "maybe": --verbose (-v)`},
+		{"jobs = x\n", nil, `line 1: invalid integer "x": --jobs`},
+		{"[net\n", nil, `line 1: invalid section "[net"`},
+	}
+	for _, test := range errs {
+		_, err := ParseINI(strings.NewReader(test.input), options)
+		var cerr ConfigError
+		if !errors.As(err, &cerr) {
+			t.Errorf("ParseINI(%q), got %v, want ConfigError", test.input, err)
+			continue
+		}
+		if test.err != nil && !errors.Is(err, test.err) {
+			t.Errorf("ParseINI(%q), got %v, want %v", test.input, err, test.err)
+		}
+		if err.Error() != test.msg {
+			t.Errorf("ParseINI(%q), got %q, want %q", test.input, err, test.msg)
+		}
+	}
+}
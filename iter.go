@@ -0,0 +1,50 @@
+// This is free and unencumbered software released into the public domain.
+
+package optparse
+
+import (
+	"iter"
+)
+
+// All returns an iterator over the options parsed from args, without
+// materializing a slice of results. If parsing fails, the final pair
+// holds the error. After the last option, each Required option not
+// given yields an error wrapping ErrRequired. Breaking out of the loop stops parsing early. Since
+// the remaining arguments are not reported, use Parse when they are
+// needed.
+//
+//	for result, err := range optparse.All(options, os.Args) {
+//		if err != nil {
+//			// ...
+//		}
+//		switch result.Long {
+//		// ...
+//		}
+//	}
+func All(options []Option, args []string) iter.Seq2[Result, error] {
+	return func(yield func(Result, error) bool) {
+		p := NewParser(options, args)
+		var seen Results // only those of Required options
+		for {
+			result, ok, err := p.next()
+			if err != nil {
+				yield(Result{}, err)
+				return
+			}
+			if !ok {
+				break
+			}
+			if result.Required {
+				seen = append(seen, result)
+			}
+			if !yield(result, nil) {
+				return
+			}
+		}
+		for _, err := range p.missing(options, seen) {
+			if !yield(Result{}, err) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package optparse
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestAll(t *testing.T) {
+	var longs []string
+	for result, err := range All(options, []string{"", "-ab", "--delay=1", "x", "-e"}) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		longs = append(longs, result.Long)
+	}
+	if want := []string{"amend", "brief", "delay"}; !equal(longs, want) {
+		t.Errorf("All(), got %q, want %q", longs, want)
+	}
+
+	longs = nil
+	var last error
+	for result, err := range All(options, []string{"", "-ax", "-b"}) {
+		longs = append(longs, result.Long)
+		last = err
+	}
+	if !errors.Is(last, ErrInvalid) || len(longs) != 2 {
+		t.Errorf("All(), got %q %v, want error", longs, last)
+	}
+
+	count := 0
+	for range All(options, []string{"", "-eeee"}) {
+		count++
+		if count == 2 {
+			break
+		}
+	}
+	if count != 2 {
+		t.Errorf("All(), got %d iterations, want 2", count)
+	}
+
+	required := []Option{
+		{Long: "name", Kind: KindRequired, Required: true},
+		{Long: "id", Kind: KindRequired, Required: true},
+		{Long: "verbose", Short: 'v', Kind: KindNone},
+	}
+	var errs []error
+	for _, err := range All(required, []string{"", "-v", "--id=1"}) {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) != 1 || !errors.Is(errs[0], ErrRequired) || errs[0].Error() != "missing required option: --name" {
+		t.Errorf("All(), got %v, want missing --name", errs)
+	}
+}
@@ -0,0 +1,136 @@
+// This is free and unencumbered software released into the public domain.
+
+package optparse
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// ParseJSON is like ParseINI but reads a JSON config file, such as one
+// generated by another program. The file is an object whose keys name
+// options, and nested objects name them by the dotted path of keys, so
+// "delay" within "net" sets --net.delay. Arrays give repeated options,
+// booleans set options without an argument, as does null, and numbers
+// are passed as written.
+func ParseJSON(r io.Reader, options []Option) (Results, error) {
+	return parseConfig(readJSON, r, options)
+}
+
+// MergeJSON is like MergeTOML but reads a JSON config file, with
+// nested objects in place of tables.
+func (inv *Invocation) MergeJSON(r io.Reader) error {
+	entries, err := readJSON(r)
+	if err != nil {
+		return err
+	}
+	return inv.merge(entries)
+}
+
+// readJSON reads the entries of a JSON file up to the first error.
+func readJSON(r io.Reader) ([]entry, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	j := jsonReader{data: data, d: json.NewDecoder(bytes.NewReader(data))}
+	j.d.UseNumber()
+
+	if token, err := j.d.Token(); err != nil {
+		return nil, j.fail(err)
+	} else if token != json.Delim('{') {
+		return nil, j.fail(errors.New("expected an object"))
+	}
+	if err := j.object(nil); err != nil {
+		return j.entries, err
+	}
+	if _, err := j.d.Token(); err != io.EOF {
+		return j.entries, j.fail(errors.New("unexpected data after object"))
+	}
+	return j.entries, nil
+}
+
+// jsonReader accumulates the entries of a JSON file.
+type jsonReader struct {
+	data    []byte
+	d       *json.Decoder
+	entries []entry
+}
+
+// line returns the current line number.
+func (j *jsonReader) line() int {
+	return 1 + bytes.Count(j.data[:j.d.InputOffset()], []byte("\n"))
+}
+
+func (j *jsonReader) fail(err error) error {
+	return ConfigError{Line: j.line(), Err: err}
+}
+
+// object reads the members of an object following its opening brace.
+func (j *jsonReader) object(path []string) error {
+	for j.d.More() {
+		token, err := j.d.Token()
+		if err != nil {
+			return j.fail(err)
+		}
+		key := append(path[:len(path):len(path)], token.(string))
+
+		token, err = j.d.Token()
+		switch {
+		case err != nil:
+			return j.fail(err)
+		case token == json.Delim('{'):
+			if err := j.object(key); err != nil {
+				return err
+			}
+		case token == json.Delim('['):
+			for j.d.More() {
+				token, err := j.d.Token()
+				if err != nil {
+					return j.fail(err)
+				}
+				if err := j.value(key, token, false); err != nil {
+					return err
+				}
+			}
+			if _, err := j.d.Token(); err != nil {
+				return j.fail(err)
+			}
+		default:
+			if err := j.value(key, token, true); err != nil {
+				return err
+			}
+		}
+	}
+	if _, err := j.d.Token(); err != nil {
+		return j.fail(err)
+	}
+	return nil
+}
+
+// value adds the entry for a scalar. Null is permitted only outside
+// arrays.
+func (j *jsonReader) value(path []string, token json.Token, null bool) error {
+	e := entry{path: path, set: true, line: j.line()}
+	switch v := token.(type) {
+	case string:
+		e.value = v
+	case json.Number:
+		e.value = v.String()
+	case bool:
+		e.value = strconv.FormatBool(v)
+	case nil:
+		if !null {
+			return j.fail(errors.New("unexpected null in array"))
+		}
+		e.set = false
+	default:
+		return j.fail(fmt.Errorf("unexpected %v in array", v))
+	}
+	j.entries = append(j.entries, e)
+	return nil
+}
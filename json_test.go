@@ -0,0 +1,91 @@
+package optparse
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseJSON(t *testing.T) {
+	options := []Option{
+		{Long: "color", Kind: KindOptional},
+		{Long: "verbose", Short: 'v', Kind: KindNone},
+		{Long: "quiet", Kind: KindNone},
+		{Long: "jobs", Kind: KindRequired, Type: TypeInt},
+		{Long: "include", Short: 'I', Kind: KindRequired},
+		{Long: "net.delay", Kind: KindRequired},
+	}
+	input := `{
+	"color": null,
+	"verbose": true,
+	"quiet": false,
+	"jobs": 4,
+	"include": ["a", "b"],
+	"net": {"delay": 1.5}
+}`
+	results, err := ParseJSON(strings.NewReader(input), options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct {
+		long, optarg string
+		line         int
+	}{
+		{"color", "", 2},
+		{"verbose", "", 3},
+		{"jobs", "4", 5},
+		{"include", "a", 6},
+		{"include", "b", 6},
+		{"net.delay", "1.5", 7},
+	}
+	if len(results) != len(want) {
+		t.Fatalf("ParseJSON(), got %d results, want %d", len(results), len(want))
+	}
+	for i, w := range want {
+		r := results[i]
+		if r.Long != w.long || r.Optarg != w.optarg || r.Index != w.line {
+			t.Errorf("ParseJSON()[%d], got %q %q %d, want %q %q %d",
+				i, r.Long, r.Optarg, r.Index, w.long, w.optarg, w.line)
+		}
+	}
+
+	errs := []struct {
+		input string
+		msg   string
+	}{
+		{`{"bogus": 1}`, "line 1: invalid option: --bogus"},
+		{`["jobs"]`, "line 1: expected an object"},
+		{"{\n\"jobs\": [1, [2]]}", "line 2: unexpected [ in array"},
+		{`{"include": [null]}`, "line 1: unexpected null in array"},
+		{`{"jobs": 1} {}`, "line 1: unexpected data after object"},
+		{"{\n\"jobs\" 1}", "line 2: invalid character '1' after object key"},
+	}
+	for _, test := range errs {
+		_, err := ParseJSON(strings.NewReader(test.input), options)
+		var cerr ConfigError
+		if !errors.As(err, &cerr) || err.Error() != test.msg {
+			t.Errorf("ParseJSON(%q), got %v, want %q", test.input, err, test.msg)
+		}
+	}
+}
+
+func TestMergeJSON(t *testing.T) {
+	root := &Command{
+		Name:     "app",
+		Options:  []Option{{Long: "color", Kind: KindRequired}},
+		Commands: []*Command{{Name: "run", Options: []Option{{Long: "jobs", Kind: KindRequired}}}},
+	}
+	inv, err := root.Parse([]string{"app", "--color=always", "run"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	input := `{"color": "never", "run": {"jobs": 8}}`
+	if err := inv.MergeJSON(strings.NewReader(input)); err != nil {
+		t.Fatal(err)
+	}
+	color, _ := inv.Results[0].Get("color")
+	jobs, _ := inv.Results[1].Get("jobs")
+	if color != "always" || jobs != "8" {
+		t.Errorf("MergeJSON(), got %q %q, want always 8", color, jobs)
+	}
+}
@@ -0,0 +1,83 @@
+// This is free and unencumbered software released into the public domain.
+
+package optparse
+
+import (
+	"errors"
+	"slices"
+	"strings"
+)
+
+// load is a config file whose settings were injected into the
+// arguments, ending before end.
+type load struct {
+	path string
+	end  int
+}
+
+// load reads the config file at path, for the Load option at index,
+// and injects its settings. Files loading themselves, directly or
+// indirectly, are an error.
+func (p *Parser) load(index int, path string) error {
+	for len(p.loads) > 0 && p.loads[len(p.loads)-1].end <= index {
+		p.loads = p.loads[:len(p.loads)-1]
+	}
+	if slices.ContainsFunc(p.loads, func(l load) bool { return l.path == path }) {
+		var chain []string
+		for _, l := range p.loads {
+			chain = append(chain, l.path)
+		}
+		return errors.New("config file cycle: " + strings.Join(append(chain, path), " -> "))
+	}
+
+	var tokens []string
+	err := readConfigFile(path, func(entries []entry) error {
+		for _, e := range entries {
+			t, err := p.token(e)
+			if err != nil {
+				return err
+			}
+			tokens = append(tokens, t...)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	at := p.Index()
+	p.Inject(tokens...)
+	for i := range p.loads {
+		p.loads[i].end += len(tokens)
+	}
+	p.loads = append(p.loads, load{path, at + len(tokens)})
+	return nil
+}
+
+// token converts a config file setting into long option arguments,
+// none for an option without an argument set to false. The value of a
+// KindUntil option is split into words, followed by its terminator.
+func (p *Parser) token(e entry) ([]string, error) {
+	name := strings.Join(e.path, ".")
+	fail := func(option Option, err error) ([]string, error) {
+		return nil, ConfigError{Line: e.line, Err: Error{Option: option, Err: err}}
+	}
+	option := p.findLong(name)
+	if option == nil {
+		return fail(Option{Long: name}, ErrInvalid)
+	}
+	if option.Kind != KindNone && option.Kind != KindUntil && e.set {
+		return []string{"--" + name + "=" + e.value}, nil
+	}
+	result, on, err := assign(*option, e.value, e.set)
+	switch {
+	case err != nil:
+		return fail(*option, err)
+	case !on:
+		return nil, nil
+	case option.Kind == KindUntil:
+		tokens := append([]string{"--" + name}, result.Optargs...)
+		return append(tokens, option.until()), nil
+	}
+	return []string{"--" + name}, nil
+}
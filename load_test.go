@@ -0,0 +1,126 @@
+package optparse
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o666); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	base := write("base.ini", "jobs = 2\nverbose = yes\nquiet = no\n")
+	app := write("app.toml", "color = 'never'\nconfig = '"+base+"'\ninclude = ['x', 'y']\n")
+	bad := write("bad.yaml", "\nbogus: 1\n")
+	cycle := write("cycle.json", `{"config": "`+filepath.Join(dir, "cycle2.ini")+`"}`)
+	write("cycle2.ini", "config = "+cycle+"\n")
+
+	options := []Option{
+		{Long: "config", Short: 'C', Kind: KindRequired, Load: true},
+		{Long: "color", Kind: KindRequired},
+		{Long: "jobs", Kind: KindRequired, Type: TypeInt},
+		{Long: "include", Short: 'I', Kind: KindRequired},
+		{Long: "verbose", Short: 'v', Kind: KindNone},
+		{Long: "quiet", Kind: KindNone},
+	}
+	args := []string{"", "-v", "-C", app, "--jobs=3", "rest"}
+	results, rest, err := Parse(options, args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, result := range results {
+		got = append(got, result.Raw...)
+	}
+	want := []string{
+		"-v", "-C", app, "--color=never", "--config=" + base,
+		"--jobs=2", "--verbose", "--include=x", "--include=y", "--jobs=3",
+	}
+	if !equal(got, want) || !equal(rest, []string{"rest"}) {
+		t.Errorf("Parse(), got %q %q, want %q", got, rest, want)
+	}
+	if jobs, _ := results.Get("jobs"); jobs != "3" {
+		t.Errorf("Parse(), got jobs %q", jobs)
+	}
+	if args[3] != app || len(args) != 6 {
+		t.Errorf("Parse() modified args, got %q", args)
+	}
+
+	_, _, err = Parse(options, []string{"", "--config", bad})
+	if want := bad + ":2: invalid option: --bogus: --config (-C)"; err == nil ||
+		err.Error() != want || !errors.Is(err, ErrInvalid) {
+		t.Errorf("Parse(), got %v, want %q", err, want)
+	}
+
+	// a config file error is not an unrecognized option to skip
+	for _, config := range []Config{{Lenient: true}, {AllErrors: true}} {
+		results, _, err = ParseWith(config, options, []string{"", "--config", bad, "-v", "x"})
+		if !errors.Is(err, ErrInvalid) || config.Lenient && len(results) != 0 ||
+			config.AllErrors && (len(results) != 1 || results[0].Long != "verbose") {
+			t.Errorf("ParseWith(%+v), got %v %v", config, results, err)
+		}
+		_, _, err = ParseWith(config, options, []string{"", "--config", bad})
+		if !errors.Is(err, ErrInvalid) {
+			t.Errorf("ParseWith(%+v), got %v, want %v", config, err, ErrInvalid)
+		}
+	}
+	next := NewParser(options, []string{"", "--config", bad})
+	if _, _, err := next.Next(); !errors.Is(err, ErrInvalid) {
+		t.Errorf("Next(), got %v, want %v", err, ErrInvalid)
+	}
+	if _, ok, err := next.Next(); ok || err != nil {
+		t.Errorf("Next(), got %v %v, want end of options", ok, err)
+	}
+
+	_, _, err = Parse(options, []string{"", "--config", cycle})
+	var e Error
+	if !errors.As(err, &e) || e.Err.Error() != "config file cycle: "+cycle+" -> "+
+		filepath.Join(dir, "cycle2.ini")+" -> "+cycle {
+		t.Errorf("Parse(), got %v", err)
+	}
+
+	_, _, err = Parse(options, []string{"", "--config", filepath.Join(dir, "missing")})
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Parse(), got %v, want %v", err, os.ErrNotExist)
+	}
+
+	// the same file may be loaded again
+	_, _, err = Parse(options, []string{"", "-C", base, "-C", base})
+	if err != nil {
+		t.Errorf("Parse(), got %v", err)
+	}
+
+	// an until option's value is split and terminated in place
+	exec := write("exec.ini", "exec = cmd 'a b'\n")
+	until := append(options, Option{Long: "exec", Kind: KindUntil})
+	results, rest, err = Parse(until, []string{"", "-C", exec, "-v", "x", ";"})
+	if err != nil || len(results) != 3 || !equal(results[1].Optargs, []string{"cmd", "a b"}) ||
+		results[2].Long != "verbose" || !equal(rest, []string{"x", ";"}) {
+		t.Errorf("Parse(), got %v %q %v", results, rest, err)
+	}
+
+	// peeking does not load
+	p := NewParser(options, []string{"", "-C", base})
+	if result, ok, err := p.Peek(); !ok || err != nil || !result.Load {
+		t.Fatalf("Peek(), got %v %v %v", result, ok, err)
+	}
+	for {
+		_, ok, err := p.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !ok {
+			break
+		}
+	}
+	if p.Index() != 5 {
+		t.Errorf("Next(), got Index() %d, want 5", p.Index())
+	}
+}
@@ -0,0 +1,64 @@
+// This is free and unencumbered software released into the public domain.
+
+package optparse
+
+import (
+	"encoding/json"
+	"slices"
+)
+
+// jsonResult is the JSON form of a Result.
+type jsonResult struct {
+	Option  string   `json:"option"`
+	Optarg  *string  `json:"optarg,omitempty"`
+	Optargs []string `json:"optargs,omitempty"`
+	Index   int      `json:"index"`
+	Source  string   `json:"source"`
+	Negated bool     `json:"negated,omitempty"`
+}
+
+// MarshalJSON encodes the result as an object with the option's
+// canonical name, its argument or arguments, if any, masked if
+// Sensitive, its Index, its Source, and "negated" if Negated:
+//
+//	{"option": "jobs", "optarg": "4", "index": 2, "source": "command line"}
+func (r Result) MarshalJSON() ([]byte, error) {
+	return json.Marshal(r.json())
+}
+
+// json converts the result to its JSON form.
+func (r Result) json() jsonResult {
+	j := jsonResult{Option: r.name(), Index: r.Index, Source: r.Source.String(), Negated: r.Negated}
+	if r.Kind == KindUntil {
+		j.Optargs = r.Optargs
+		if r.Sensitive {
+			j.Optargs = slices.Repeat([]string{mask}, len(r.Optargs))
+		}
+	} else if r.Kind == KindRequired || r.Attached || r.Optarg != "" {
+		optarg := r.Optarg
+		if r.Sensitive {
+			optarg = mask
+		}
+		j.Optarg = &optarg
+	}
+	return j
+}
+
+// MarshalJSON encodes the results as an object holding each Result in
+// order and the number of occurrences of each option by canonical name:
+//
+//	{
+//	  "results": [{"option": "verbose", "index": 1, "source": "command line"}],
+//	  "counts": {"verbose": 1}
+//	}
+func (r Results) MarshalJSON() ([]byte, error) {
+	j := struct {
+		Results []jsonResult   `json:"results"`
+		Counts  map[string]int `json:"counts"`
+	}{[]jsonResult{}, map[string]int{}}
+	for _, result := range r {
+		j.Results = append(j.Results, result.json())
+		j.Counts[result.name()]++
+	}
+	return json.Marshal(j)
+}
@@ -0,0 +1,48 @@
+package optparse
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestMarshalJSON(t *testing.T) {
+	options := []Option{
+		{Long: "verbose", Short: 'v', Kind: KindNone},
+		{Long: "color", Kind: KindOptional},
+		{Short: 'j', Kind: KindRequired},
+		{Long: "token", Kind: KindRequired, Sensitive: true},
+	}
+	table := []struct {
+		args []string
+		want string
+	}{
+		{[]string{""}, `{"results":[],"counts":{}}`},
+		{
+			[]string{"", "-vv", "--color", "-j4", "--token", "abc"},
+			`{"results":[` +
+				`{"option":"verbose","index":1,"source":"command line"},` +
+				`{"option":"verbose","index":1,"source":"command line"},` +
+				`{"option":"color","index":2,"source":"command line"},` +
+				`{"option":"j","optarg":"4","index":3,"source":"command line"},` +
+				`{"option":"token","optarg":"****","index":4,"source":"command line"}],` +
+				`"counts":{"color":1,"j":1,"token":1,"verbose":2}}`,
+		},
+		{
+			[]string{"", "--color="},
+			`{"results":[{"option":"color","optarg":"","index":1,"source":"command line"}],"counts":{"color":1}}`,
+		},
+	}
+	for _, row := range table {
+		results, _, err := Parse(options, row.args)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := json.Marshal(results)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != row.want {
+			t.Errorf("Marshal(%q), got:\n%s\nwant:\n%s", row.args, got, row.want)
+		}
+	}
+}
@@ -0,0 +1,236 @@
+// This is free and unencumbered software released into the public domain.
+
+package optparse
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrOperand indicates a required operand was not given.
+	ErrOperand = errors.New("missing operand")
+
+	// ErrExtraOperand indicates more operands than were declared.
+	ErrExtraOperand = errors.New("extra operand")
+)
+
+// Operand declares a positional argument, or operand, following the
+// options, such as the SOURCE and DEST of cp, for ParseOperands and
+// Command.Operands.
+type Operand struct {
+	// Name identifies the operand in Positionals and errors, and names
+	// it in help, such as "SOURCE".
+	Name string
+
+	// Required makes it an error, ErrOperand, for the operand to be
+	// absent. Required operands precede optional ones.
+	Required bool
+
+	// Variadic makes the last operand capture all remaining arguments,
+	// at least Min, or one if Required, and at most Max unless zero,
+	// such as the FILE... of cat.
+	Variadic bool
+	Min, Max int
+
+	// Type, if not TypeString, has the argument converted as for an
+	// option, delivered in Positional.Value.
+	Type Type
+
+	// Help is a short description of the operand shown by Usage.
+	Help string
+}
+
+// Positional is an operand matched to its declaration, with Value
+// converted according to its Type. Index is the position of Arg in the
+// arguments given to ParseOperands.
+type Positional struct {
+	Operand
+	Arg   string
+	Value any
+	Index int
+}
+
+// Positionals is a slice of operands with a lookup by name.
+type Positionals []Positional
+
+// Get returns the named operand's argument, reporting whether it was
+// given.
+func (p Positionals) Get(name string) (string, bool) {
+	for _, positional := range p {
+		if positional.Name == name {
+			return positional.Arg, true
+		}
+	}
+	return "", false
+}
+
+// GetAll returns the arguments of the named operand, in order, for a
+// variadic operand.
+func (p Positionals) GetAll(name string) []string {
+	var args []string
+	for _, positional := range p {
+		if positional.Name == name {
+			args = append(args, positional.Arg)
+		}
+	}
+	return args
+}
+
+// OperandError reports operands not matching their declarations. It
+// embeds the declaration, empty for an extra operand, and Err is
+// ErrOperand, ErrExtraOperand, or, for a bad argument, a description of
+// the problem. Index and Arg identify the argument, if any. For the
+// first two, Least and Most are the numbers of operands accepted, with
+// a Most of -1 for no limit, and Got is the number given. Implements
+// error.
+type OperandError struct {
+	Operand
+	Err         error
+	Index       int
+	Arg         string
+	Least, Most int
+	Got         int
+}
+
+// Error describes the problem, with arity errors in the form "expected
+// exactly 2 arguments, got 3: extra argument 'x'".
+func (e OperandError) Error() string {
+	switch e.Err {
+	case ErrOperand:
+		return fmt.Sprintf("%s: missing %s", e.expected(), e.Name)
+	case ErrExtraOperand:
+		return fmt.Sprintf("%s: extra argument '%s'", e.expected(), e.Arg)
+	}
+	return fmt.Sprintf("%s: %s", e.Err, e.Name)
+}
+
+// expected describes the accepted and given numbers of operands.
+func (e OperandError) expected() string {
+	var want string
+	switch {
+	case e.Most == 0:
+		want = "no arguments"
+	case e.Least == e.Most:
+		want = "exactly " + arguments(e.Least)
+	case e.Most < 0:
+		want = "at least " + arguments(e.Least)
+	case e.Least == 0:
+		want = "at most " + arguments(e.Most)
+	default:
+		want = fmt.Sprintf("%d to %s", e.Least, arguments(e.Most))
+	}
+	return fmt.Sprintf("expected %s, got %d", want, e.Got)
+}
+
+// arguments formats a count of arguments.
+func arguments(n int) string {
+	if n == 1 {
+		return "1 argument"
+	}
+	return fmt.Sprintf("%d arguments", n)
+}
+
+// Unwrap returns Err.
+func (e OperandError) Unwrap() error {
+	return e.Err
+}
+
+// Arity returns the least and most numbers of operands accepted by the
+// declarations, with a most of -1 for no limit.
+func Arity(operands []Operand) (least, most int) {
+	for _, operand := range operands {
+		l, m := operand.bounds()
+		least += l
+		switch {
+		case most < 0:
+		case m < 0:
+			most = -1
+		default:
+			most += m
+		}
+	}
+	return least, most
+}
+
+// bounds returns the least and most number of arguments matched by the
+// operand, with a most of -1 for no limit.
+func (o Operand) bounds() (least, most int) {
+	if o.Required {
+		least = 1
+	}
+	if !o.Variadic {
+		return least, 1
+	}
+	least = max(least, o.Min)
+	if o.Max > 0 {
+		return least, o.Max
+	}
+	return least, -1
+}
+
+// CheckOperands validates the number of operands, such as the arguments
+// remaining after Parse, against their declarations, returning an
+// OperandError wrapping ErrOperand or ErrExtraOperand on mismatch.
+func CheckOperands(operands []Operand, rest []string) error {
+	least, most := Arity(operands)
+	e := OperandError{Least: least, Most: most, Got: len(rest)}
+	n := 0 // arguments matched
+	for _, operand := range operands {
+		l, m := operand.bounds()
+		if m < 0 {
+			m = len(rest)
+		}
+		if len(rest)-n < l {
+			e.Operand, e.Err, e.Index = operand, ErrOperand, len(rest)
+			return e
+		}
+		n += min(m, len(rest)-n)
+	}
+	if n < len(rest) {
+		e.Err, e.Index, e.Arg = ErrExtraOperand, n, rest[n]
+		return e
+	}
+	return nil
+}
+
+// ParseOperands matches operands, such as the arguments remaining after
+// Parse, to their declarations in order, converting each according to
+// its Type. The number of operands is validated as with CheckOperands
+// after converting those that match.
+func ParseOperands(operands []Operand, rest []string) (Positionals, error) {
+	var positionals Positionals
+	n := 0 // arguments matched
+	for _, operand := range operands {
+		_, most := operand.bounds()
+		if most < 0 {
+			most = len(rest)
+		}
+		for end := n + min(most, len(rest)-n); n < end; n++ {
+			value, err := convert(operand.Type, rest[n])
+			if err != nil {
+				return positionals, OperandError{Operand: operand, Err: err, Index: n, Arg: rest[n]}
+			}
+			positionals = append(positionals, Positional{operand, rest[n], value, n})
+		}
+	}
+	return positionals, CheckOperands(operands, rest)
+}
+
+// operandSynopsis formats the operands for a usage line, such as
+// "SOURCE [DEST]".
+func operandSynopsis(operands []Operand) string {
+	var parts []string
+	for _, operand := range operands {
+		part := operand.Name
+		if !operand.Required && !(operand.Variadic && operand.Min > 0) {
+			part = "[" + part + "]"
+		}
+		if operand.Variadic {
+			part += "..."
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,170 @@
+package optparse
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseOperands(t *testing.T) {
+	operands := []Operand{
+		{Name: "SOURCE", Required: true},
+		{Name: "DEST", Required: true},
+		{Name: "COUNT", Type: TypeInt},
+	}
+	positionals, err := ParseOperands(operands, []string{"a", "b", "3"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := positionals.Get("DEST"); !ok || got != "b" {
+		t.Errorf(`Get("DEST"), got %q %v, want "b" true`, got, ok)
+	}
+	if got, ok := positionals.Get("OTHER"); ok || got != "" {
+		t.Errorf(`Get("OTHER"), got %q %v, want "" false`, got, ok)
+	}
+	if positionals[2].Value != 3 || positionals[2].Index != 2 || positionals[0].Value != nil {
+		t.Errorf("ParseOperands(), got %v", positionals)
+	}
+
+	positionals, err = ParseOperands(operands, []string{"a", "b"})
+	if err != nil || len(positionals) != 2 {
+		t.Errorf("ParseOperands(), got %v %v", positionals, err)
+	}
+
+	table := []struct {
+		rest  []string
+		err   error
+		index int
+		msg   string
+	}{
+		{[]string{"a"}, ErrOperand, 1, "expected 2 to 3 arguments, got 1: missing DEST"},
+		{nil, ErrOperand, 0, "expected 2 to 3 arguments, got 0: missing SOURCE"},
+		{[]string{"a", "b", "3", "x"}, ErrExtraOperand, 3, "expected 2 to 3 arguments, got 4: extra argument 'x'"},
+		{[]string{"a", "b", "x"}, nil, 2, `invalid integer "x": COUNT`},
+	}
+	for _, test := range table {
+		_, err := ParseOperands(operands, test.rest)
+		var e OperandError
+		if !errors.As(err, &e) || test.err != nil && !errors.Is(err, test.err) ||
+			e.Index != test.index || err.Error() != test.msg {
+			t.Errorf("ParseOperands(%q), got %v (%d), want %q (%d)",
+				test.rest, err, e.Index, test.msg, test.index)
+		}
+	}
+}
+
+func TestCommandOperands(t *testing.T) {
+	root := &Command{
+		Name:     "cp",
+		Options:  []Option{{Long: "force", Short: 'f', Kind: KindNone}},
+		Operands: []Operand{{Name: "SOURCE", Required: true}, {Name: "DEST"}},
+	}
+	inv, err := root.Parse([]string{"cp", "-f", "--", "-a", "b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := inv.Operands.Get("SOURCE"); got != "-a" || inv.Operands[1].Index != 4 {
+		t.Errorf("Parse(), got %v", inv.Operands)
+	}
+
+	_, err = root.Parse([]string{"cp", "-f", "a", "b", "c"})
+	var e OperandError
+	if !errors.As(err, &e) || e.Index != 4 || e.Arg != "c" {
+		t.Errorf("Parse(), got %v, want extra operand at 4", err)
+	}
+}
+
+func TestParseOperandsVariadic(t *testing.T) {
+	table := []struct {
+		operands []Operand
+		rest     []string
+		want     []string // FILE arguments
+		err      error
+		index    int
+	}{
+		{[]Operand{{Name: "FILE", Variadic: true}}, nil, nil, nil, 0},
+		{[]Operand{{Name: "FILE", Variadic: true}}, []string{"a", "b"}, []string{"a", "b"}, nil, 0},
+		{[]Operand{{Name: "FILE", Variadic: true, Required: true}}, nil, nil, ErrOperand, 0},
+		{[]Operand{{Name: "FILE", Variadic: true, Min: 2}}, []string{"a"}, []string{"a"}, ErrOperand, 1},
+		{[]Operand{{Name: "FILE", Variadic: true, Max: 2}}, []string{"a", "b", "c"}, []string{"a", "b"}, ErrExtraOperand, 2},
+		{
+			[]Operand{{Name: "DEST", Required: true}, {Name: "FILE", Variadic: true, Min: 1, Max: 2}},
+			[]string{"d", "a", "b"}, []string{"a", "b"}, nil, 0,
+		},
+		{
+			[]Operand{{Name: "DEST", Required: true}, {Name: "FILE", Variadic: true, Min: 1}},
+			[]string{"d"}, nil, ErrOperand, 1,
+		},
+	}
+	for _, test := range table {
+		positionals, err := ParseOperands(test.operands, test.rest)
+		if got := positionals.GetAll("FILE"); !equal(got, test.want) {
+			t.Errorf("ParseOperands(%q), got %q, want %q", test.rest, got, test.want)
+		}
+		var e OperandError
+		switch {
+		case test.err == nil && err != nil:
+			t.Errorf("ParseOperands(%q), got %v", test.rest, err)
+		case test.err != nil && (!errors.Is(err, test.err) || !errors.As(err, &e) || e.Index != test.index):
+			t.Errorf("ParseOperands(%q), got %v (%d), want %v (%d)",
+				test.rest, err, e.Index, test.err, test.index)
+		}
+	}
+}
+
+func TestCheckOperands(t *testing.T) {
+	table := []struct {
+		operands []Operand
+		rest     []string
+		msg      string
+	}{
+		{
+			[]Operand{{Name: "SOURCE", Required: true}, {Name: "DEST", Required: true}},
+			[]string{"a", "b", "x"},
+			"expected exactly 2 arguments, got 3: extra argument 'x'",
+		},
+		{
+			[]Operand{{Name: "SOURCE", Required: true}},
+			nil,
+			"expected exactly 1 argument, got 0: missing SOURCE",
+		},
+		{
+			nil,
+			[]string{"x"},
+			"expected no arguments, got 1: extra argument 'x'",
+		},
+		{
+			[]Operand{{Name: "FILE", Variadic: true, Min: 1}},
+			nil,
+			"expected at least 1 argument, got 0: missing FILE",
+		},
+		{
+			[]Operand{{Name: "FILE", Variadic: true, Max: 2}},
+			[]string{"a", "b", "c"},
+			"expected at most 2 arguments, got 3: extra argument 'c'",
+		},
+		{
+			[]Operand{{Name: "DEST", Required: true}, {Name: "FILE", Variadic: true, Min: 2, Max: 3}},
+			[]string{"d", "a"},
+			"expected 3 to 4 arguments, got 2: missing FILE",
+		},
+		{
+			[]Operand{{Name: "DEST", Required: true}, {Name: "FILE", Variadic: true}},
+			[]string{"d", "a", "b"},
+			"",
+		},
+	}
+	for _, test := range table {
+		err := CheckOperands(test.operands, test.rest)
+		var msg string
+		if err != nil {
+			msg = err.Error()
+		}
+		if msg != test.msg {
+			t.Errorf("CheckOperands(%q), got %q, want %q", test.rest, msg, test.msg)
+		}
+	}
+
+	if least, most := Arity([]Operand{{Required: true}, {Variadic: true, Min: 2}}); least != 3 || most != -1 {
+		t.Errorf("Arity(), got %d %d, want 3 -1", least, most)
+	}
+}
@@ -9,11 +9,20 @@
 // with the arguments string slice, to the Parse() function. It will
 // return a slice of parsing results, which is to be iterated over just
 // like getopt().
+//
+// Behavior beyond that of getopt_long() is opt-in, selected per parse by
+// the fields of a Config passed to ParseWith().
 package optparse
 
 import (
+	"errors"
 	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
+	"unicode/utf8"
 )
 
 const (
@@ -23,13 +32,28 @@ const (
 	KindRequired
 	// KindOptional means the argument is optional
 	KindOptional
+	// KindUntil means the option takes every following argument up to
+	// its Until terminator, as with find -exec
+	KindUntil
+)
 
+// Errors wrapped by Error, for use with errors.Is.
+var (
 	// ErrInvalid is used when an option is not recognized.
-	ErrInvalid = "invalid option"
+	ErrInvalid = errors.New("invalid option")
 	// ErrMissing is used when a required argument is missing.
-	ErrMissing = "option requires an argument"
+	ErrMissing = errors.New("option requires an argument")
 	// ErrTooMany is used when an unwanted argument is provided.
-	ErrTooMany = "option takes no arguments"
+	ErrTooMany = errors.New("option takes no arguments")
+	// ErrRequired is used when a required option was not given. Since
+	// there is no offending argument, Index and Arg are zero.
+	ErrRequired = errors.New("missing required option")
+	// ErrDeprecated is used to warn of a deprecated option, which is
+	// not an error.
+	ErrDeprecated = errors.New("deprecated option")
+	// ErrSecret is used when a Secret option's argument is given on the
+	// command line without Config.SecretArgs.
+	ErrSecret = errors.New("secret not accepted on the command line")
 )
 
 // Kind is an enumeration indicating how an option is used.
@@ -43,33 +67,412 @@ type Option struct {
 	Long  string
 	Short rune
 	Kind  Kind
+
+	// Until is the argument ending the arguments of a KindUntil option,
+	// which is consumed with them. If empty, it is ";".
+	Until string
+
+	// ArgName names the argument in help and error messages, as in
+	// --delay=SECONDS. If empty, it is ARG.
+	ArgName string
+
+	// Prefix makes Long a prefix claiming every long option that begins
+	// with it but is not otherwise defined, such as "x-" for
+	// --x-plugin-level. Each Result carries the full name in Long.
+	Prefix bool
+
+	// Type, if not TypeString, has the parser convert the option's
+	// argument, delivering it in Result.Value, and reject arguments
+	// that fail to convert.
+	Type Type
+
+	// Choices, if not empty, lists the only acceptable arguments.
+	Choices []string
+
+	// Pattern, if not nil, must match the argument. Like any regular
+	// expression it matches anywhere unless anchored with ^ and $.
+	Pattern *regexp.Regexp
+
+	// Min and Max, unless both zero, are inclusive bounds on the value
+	// of numeric types: TypeInt, TypeUint, and TypeFloat. For only one
+	// bound, use an infinity for the other.
+	Min, Max float64
+
+	// Validate, if not nil, is called with the argument after all other
+	// checks pass. A returned error is wrapped in an Error for this
+	// option and stops parsing.
+	Validate func(optarg string) error
+
+	// Func, if not nil, is called by Run with the argument each time
+	// the option is parsed. An option without an argument receives an
+	// empty string. It has no effect on other parsing functions.
+	Func func(optarg string) error
+
+	// Complete, if not nil, returns candidate arguments beginning with
+	// prefix, such as branch names, for shell completion computed at
+	// run time by package complete.
+	Complete func(prefix string) []string
+
+	// CompleteAs tells shell completion that the argument is a path.
+	CompleteAs Hint
+
+	// Required makes it an error, ErrRequired, for the option to be
+	// absent from an otherwise successful parse.
+	Required bool
+
+	// Persistent makes an option of a Command also accepted by all of
+	// its descendants.
+	Persistent bool
+
+	// Help is a short description of the option shown by Usage.
+	Help string
+
+	// Default and EnvVar, if not empty, are shown by Usage following
+	// Help, documenting the value assumed when the option is absent
+	// and an environment variable supplying it. Resolve applies them.
+	Default string
+	EnvVar  string
+
+	// Load makes the argument the path of a config file, such as for
+	// --config, read as with ParseConfigFile as soon as the option is
+	// parsed. Its settings are injected as long options to be parsed
+	// next, so options later on the command line override them.
+	Load bool
+
+	// Secret marks the argument as a password or the like, which would
+	// be visible to other processes on the command line. It is only
+	// accepted there with Config.SecretArgs. Otherwise the option never
+	// takes a separate argument, and given alone, it prompts for one
+	// with echo disabled when standard input is a terminal.
+	Secret bool
+
+	// Sensitive masks the argument, such as a token, as "****" in
+	// errors, in Error.Arg and Result.Raw, including those of options
+	// sharing its cluster, and in the String and JSON of its Result, so
+	// that it does not leak into logs. Errors from Validate are not
+	// masked.
+	Sensitive bool
+
+	// Separator makes the option divide the command line into groups
+	// for ParseGroups, such as curl's --next.
+	Separator bool
+
+	// Group names the section of Usage listing the option, such as
+	// "Output options".
+	Group string
+
+	// Deprecated, if not empty, marks the option as deprecated with an
+	// explanation, such as "use --color instead". The option still
+	// parses, but each use is reported to Config.Warn as an Error
+	// wrapping ErrDeprecated.
+	Deprecated string
 }
 
+// Hint is an enumeration of argument types known to shell completion.
+type Hint int
+
+const (
+	// HintNone offers no particular completion.
+	HintNone Hint = iota
+	// HintFiles completes file names.
+	HintFiles
+	// HintDirs completes directory names only.
+	HintDirs
+)
+
 // Error represents all possible parsing errors. It embeds the option
-// that has been misused, and Message is one of the three error strings.
-// Implements error.
+// that has been misused, and Err is one of the three error values or,
+// for a bad option argument, a description of the problem.
+// Index is the position in args of the offending argument, and Arg is
+// that argument exactly as given, except that the argument of a
+// Sensitive option is masked, there and in Err. For unrecognized long
+// options, Suggestions lists similarly-named long options, closest
+// first, that the user may have meant. Implements error.
 type Error struct {
 	Option
-	Message string
+	Err         error
+	Index       int
+	Arg         string
+	Suggestions []string
+
+	format func(Error) string
 }
 
 func (e Error) Error() string {
-	if e.Long != "" && e.Short != 0 {
-		return fmt.Sprintf("%s: --%s (-%c)", e.Message, e.Long, e.Short)
-	} else if e.Long != "" {
-		return fmt.Sprintf("%s: --%s", e.Message, e.Long)
-	} else {
-		return fmt.Sprintf("%s: -%c", e.Message, e.Short)
+	if e.format != nil {
+		format := e.format
+		e.format = nil
+		return format(e)
+	}
+	return e.message()
+}
+
+// message is the default error message.
+func (e Error) message() string {
+	if e.Err == ErrMissing && e.ArgName != "" {
+		return fmt.Sprintf("option %s requires %s", e.names(), e.ArgName)
 	}
+	s := fmt.Sprintf("%s: %s", e.Err, e.names())
+	if e.Err == ErrDeprecated && e.Deprecated != "" {
+		s += ": " + e.Deprecated
+	}
+	return s
+}
+
+// mask replaces the argument of a Sensitive option for display.
+const mask = "****"
+
+// conceal returns a copy of the result's Raw with the arguments of a
+// Sensitive option masked.
+func conceal(result Result) []string {
+	raw := slices.Clone(result.Raw)
+	first := result.Optarg
+	if result.Kind == KindUntil && len(result.Optargs) > 0 {
+		first = result.Optargs[0]
+	}
+	if result.Attached && first != "" {
+		raw[0] = strings.TrimSuffix(raw[0], first) + mask
+	}
+	rest := raw[1:]
+	if result.Kind == KindUntil {
+		rest = rest[:len(rest)-1] // the terminator
+	}
+	for i := range rest {
+		rest[i] = mask
+	}
+	return raw
+}
+
+// cover returns the argument at index with the attached argument of any
+// Sensitive option in a cluster of short options masked, so that it
+// does not leak through the other options of the cluster.
+func (p *Parser) cover(index int) string {
+	arg := p.args[index]
+	i := -1
+	switch {
+	case p.Plus && len(arg) > 1 && arg[0] == '+':
+		i = 1
+	case arg != "" && arg[0] != '-' && slices.Contains(p.Dashless, index):
+		i = 0
+	case len(arg) > 1 && arg[0] == '-' && arg[1] != '-':
+		i = 1
+	}
+	for i >= 0 && i < len(arg) {
+		c, size := utf8.DecodeRuneInString(arg[i:])
+		i += size
+		option := p.findShort(c)
+		if option == nil || p.kind(option) == KindNone {
+			continue
+		}
+		if optarg := p.stripEquals(arg[i:]); option.Sensitive && optarg != "" {
+			return strings.TrimSuffix(arg, optarg) + mask
+		}
+		break // the rest is an argument
+	}
+	return arg
+}
+
+// names formats the option's names for messages.
+func (o Option) names() string {
+	switch {
+	case o.Long != "" && o.Short != 0:
+		return fmt.Sprintf("--%s (-%c)", o.Long, o.Short)
+	case o.Long != "":
+		return "--" + o.Long
+	case o.Short != 0:
+		return fmt.Sprintf("-%c", o.Short)
+	default:
+		return "--" // an empty long name
+	}
+}
+
+// Unwrap returns Err so that errors.Is(err, ErrMissing) and the like
+// identify the kind of failure.
+func (e Error) Unwrap() error {
+	return e.Err
 }
 
 // Result is an individual successfully-parsed option. It embeds the
 // original Option plus any argument. For options with optional
-// arguments (KindOptional), it is not possible determine the difference
-// between an empty supplied argument or no argument supplied.
+// arguments (KindOptional), Attached distinguishes an empty supplied
+// argument (--color=) from no argument supplied (--color).
 type Result struct {
 	Option
 	Optarg string
+
+	// Optargs holds the arguments of a KindUntil option, whose Optarg
+	// is empty. The first may have been Attached.
+	Optargs []string
+
+	// Value is the argument converted according to the option's Type.
+	// It is nil for TypeString, or if there is no argument.
+	Value any
+
+	// Attached is true when Optarg was supplied within the same
+	// argument as the option (--delay=10, -d10) rather than as the
+	// following argument (--delay 10, -d 10).
+	Attached bool
+
+	// Index is the position in args of the argument containing the
+	// option, and Raw holds the exact arguments consumed to produce
+	// this result: the option itself plus any separate argument.
+	// Options grouped in a cluster (-abc) share the same Raw.
+	Index int
+	Raw   []string
+
+	// Source is where the result came from, the command line unless
+	// read from a config file or produced by Resolve.
+	Source Source
+
+	// Negated is true when the option was given with a plus in place of
+	// the dash (+x), as permitted by Config.Plus.
+	Negated bool
+}
+
+// String formats the result as a command line argument, such as
+// "--jobs=4" or "-v", with the argument of a Sensitive option masked as
+// "--token=****".
+func (r Result) String() string {
+	hasArg := r.Kind == KindRequired || r.Attached || r.Optarg != ""
+	optarg := r.Optarg
+	if r.Sensitive {
+		optarg = mask
+	}
+	name := r.flag()
+	switch {
+	case r.Kind == KindUntil:
+		optargs := r.Optargs
+		if r.Sensitive {
+			optargs = slices.Repeat([]string{mask}, len(optargs))
+		}
+		return strings.Join(append(append([]string{name}, optargs...), r.until()), " ")
+	case hasArg && strings.HasPrefix(name, "--"):
+		return name + "=" + optarg
+	case hasArg:
+		return name + optarg
+	}
+	return name
+}
+
+// flag returns the canonical form of the result's option: its long name
+// if it has one, or its short option, with a plus if Negated.
+func (r Result) flag() string {
+	switch {
+	case r.Negated:
+		return "+" + string(r.Short)
+	case r.Long != "":
+		return "--" + r.Long
+	}
+	return "-" + string(r.Short)
+}
+
+// Config adjusts the parser's behavior. The zero value selects the
+// traditional getopt_long() behavior used by Parse. Each field controls
+// one independent behavior, and fields may be combined freely.
+type Config struct {
+	// Terminator is the argument marking the end of options. It need
+	// not begin with a dash. If empty, it is "--". Otherwise a "--" is
+	// an operand, ending the options like any other.
+	Terminator string
+
+	// Bundled treats a first argument without a leading dash as a
+	// bundle of short options in the historical style of tar and ps,
+	// so that "tar xvf file" is accepted as well as "tar -xvf file".
+	// Each option in the bundle requiring an argument takes the next
+	// argument following the bundle in turn, as in "tar cbf 20 file",
+	// and a KindUntil option those through its terminator. Running out
+	// is an error wrapping ErrMissing for the whole bundle. Index and
+	// Raw refer to the unbundled arguments.
+	Bundled bool
+
+	// Plus accepts short options with a plus in place of the dash, as
+	// in "set +x", marking their results Negated. A plus cluster (+ab)
+	// negates each option in it.
+	Plus bool
+
+	// Dashless lists positions in args at which an argument without a
+	// leading dash is a cluster of short options, parsed as though it
+	// had one, in the style of BSD ps, as in "ps aux" with position 1.
+	Dashless []int
+
+	// StopAt lists arguments at which parsing stops, left in the
+	// remaining arguments unlike Terminator, such as "(" or "!" to
+	// begin a find(1)-style expression after the options.
+	StopAt []string
+
+	// NegativeNumbers treats arguments that look like negative numbers,
+	// such as -5 or -3.14, as operands rather than options, provided
+	// no short option matches the first digit.
+	NegativeNumbers bool
+
+	// Numeric, if not nil, is the option delivered for any argument
+	// consisting of a dash followed only by digits, as in "head -20",
+	// with the digits as its Optarg. It is typically one of the options
+	// in the option slice so that it may also be given by name.
+	Numeric *Option
+
+	// ShortEquals strips a leading '=' from arguments attached to short
+	// options, so that -d=10 is equivalent to -d10.
+	ShortEquals bool
+
+	// GreedyOptional allows options with optional arguments (KindOptional)
+	// to take the following argument when no argument is attached and
+	// the next argument neither begins with a dash nor is the
+	// terminator, so that "--color red" is like "--color=red".
+	GreedyOptional bool
+
+	// AllErrors continues parsing past invalid options, skipping them,
+	// and returns every error joined with errors.Join alongside all
+	// successfully parsed results.
+	AllErrors bool
+
+	// Lenient skips unrecognized options instead of failing, so that
+	// older programs tolerate options meant for newer versions. An
+	// argument attached to an unrecognized long option (--new=value)
+	// is skipped with it, but a separate argument is indistinguishable
+	// from an operand and is left alone.
+	Lenient bool
+
+	// Warn, if not nil, is called with each error skipped in lenient
+	// mode, and with a warning for each use of a deprecated option.
+	Warn func(error)
+
+	// Format, if not nil, produces the text of each returned Error in
+	// place of the built-in English message, such as for translation.
+	// Calling Error on its argument yields the default text.
+	Format func(Error) string
+
+	// Aliases maps an argument to its replacement arguments, as with
+	// git aliases. An alias is expanded where it appears as an option
+	// argument, such as "--fast", or as the first operand, such as
+	// "co" for "checkout --quiet". Aliases at the start of an expansion,
+	// or beginning with a dash, expand recursively, and a cycle is an
+	// error. Index and Raw refer to the expanded arguments.
+	Aliases map[string][]string
+
+	// Resolver, if not nil, is consulted for options not found in the
+	// option slice.
+	Resolver Resolver
+
+	// Prompt, when standard input is a terminal, asks on standard error
+	// for each Required option absent from an otherwise successful
+	// parse, showing its Help and Default, rather than reporting
+	// ErrRequired, such as for setup commands. An empty answer takes
+	// the Default, if any, and invalid answers are asked again.
+	Prompt bool
+
+	// SecretArgs accepts the arguments of Secret options on the command
+	// line, for scripts that have no better way to supply them.
+	SecretArgs bool
+}
+
+// Resolver supplies options on demand, for open-ended families such as
+// -Wfoo or --define-X that cannot be enumerated in advance. Each method
+// returns nil for an unknown option. The returned option is delivered
+// in the Result as is, so its name need not match the one requested.
+type Resolver interface {
+	ResolveLong(long string) *Option
+	ResolveShort(short rune) *Option
 }
 
 // Parse results a slice of the parsed results, the remaining arguments,
@@ -79,68 +482,300 @@ type Result struct {
 // The first argument, args[0], is skipped, and arguments are not
 // permuted. Parsing stops at the first non-option argument, or "--".
 // The latter is not included in the remaining, unparsed arguments.
-func Parse(options []Option, args []string) ([]Result, []string, error) {
-	parser := parser{options: options, args: args}
-	var results []Result
+func Parse(options []Option, args []string) (Results, []string, error) {
+	return ParseWith(Config{}, options, args)
+}
+
+// Run parses args like Parse, but instead of collecting results it calls
+// each parsed option's Func in argument order, for programs that only
+// want the side effects. It stops at the first parsing error or the
+// first error returned by a Func, which is wrapped in an Error for that
+// option. It returns the remaining arguments.
+func Run(options []Option, args []string) ([]string, error) {
+	p := Parser{options: options, args: args}
+	var results Results
 	for {
-		result, err := parser.next()
-		if err != nil || result == nil {
-			return results, parser.rest(), err
+		result, ok, err := p.next()
+		if err == nil && !ok {
+			err = errors.Join(p.missing(p.options, results)...)
+		}
+		if err != nil || !ok {
+			return p.Rest(), err
 		}
-		results = append(results, *result)
+		results = append(results, result)
+		if result.Func == nil {
+			continue
+		}
+		if err := result.Func(result.Optarg); err != nil {
+			return p.Rest(), Error{
+				Option: result.Option,
+				Err:    err,
+				Index:  result.Index,
+				Arg:    result.Raw[0],
+			}
+		}
+	}
+}
+
+// ParseArgs is Parse applied to the program's own arguments, os.Args.
+func ParseArgs(options []Option) (Results, []string, error) {
+	return Parse(options, os.Args)
+}
+
+// MustParse is like Parse but panics if the arguments cannot be parsed.
+// It is intended for quick scripts and examples where handling the
+// error is noise.
+func MustParse(options []Option, args []string) (Results, []string) {
+	results, rest, err := Parse(options, args)
+	if err != nil {
+		panic(err)
 	}
+	return results, rest
 }
 
-// Parser represents the option parsing state between calls to next().
-// The zero value for Parser is ready to use.
-type parser struct {
+// ParseWith is like Parse but with behavior adjusted by a Config.
+func ParseWith(config Config, options []Option, args []string) (Results, []string, error) {
+	p := NewParser(options, args)
+	p.Config = config
+	return p.parse(nil)
+}
+
+// indexThreshold is the option table size beyond which ParseWith builds
+// lookup tables rather than searching linearly.
+const indexThreshold = 32
+
+// Parser represents the option parsing state between calls to Next,
+// for programs that need control beyond Parse, such as knowing where
+// parsing stopped. Config may be set before the first call to Next.
+type Parser struct {
+	Config
 	options []Option
 	args    []string
 	optind  int
 	subopt  int
+	done    bool // reached the end of options
+
+	terminated bool // consumed the terminator
+
+	expanded int // arguments before this index are already expanded
+
+	loads   []load // config files being parsed, innermost last
+	peeking bool
+
+	// optional lookup tables for large option tables
+	longs  map[string]*Option
+	shorts map[rune]*Option
+}
+
+// parse runs the parser to completion, applying the configured error
+// handling.
+func (p *Parser) parse(results Results) (Results, []string, error) {
+	start := len(results)
+	results, errs, err := p.collect(results)
+	if err == nil {
+		results = p.ask(p.options, results, start)
+		errs = append(errs, p.missing(p.options, results[start:])...)
+		err = errors.Join(errs...)
+	}
+	return results, p.Rest(), err
+}
+
+// collect appends results until the end of options, returning errors
+// skipped under AllErrors separately from an error that stopped parsing.
+func (p *Parser) collect(results Results) (Results, []error, error) {
+	var errs []error
+	for {
+		result, ok, err := p.next()
+		if err != nil && p.Lenient && invalid(err) {
+			if p.Warn != nil {
+				p.Warn(err)
+			}
+			p.skip(err)
+			continue
+		}
+		if err != nil && p.AllErrors {
+			errs = append(errs, err)
+			p.skip(err)
+			continue
+		}
+		if err != nil || !ok {
+			return results, errs, err
+		}
+		results = append(results, result)
+	}
+}
+
+// missing returns an error for each required option absent from the
+// results.
+func (p *Parser) missing(options []Option, results Results) []error {
+	var errs []error
+	for _, option := range options {
+		if !option.Required {
+			continue
+		}
+		found := false
+		for _, result := range results {
+			if option.defines(result) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			errs = append(errs, Error{
+				Option: option,
+				Err:    ErrRequired,
+				format: p.Format,
+			})
+		}
+	}
+	return errs
+}
+
+// defines reports whether the result was parsed from this option.
+func (o Option) defines(result Result) bool {
+	return result.Short == o.Short && (result.Long == o.Long ||
+		o.Prefix && result.Prefix && strings.HasPrefix(result.Long, o.Long))
 }
 
-func (p *parser) short() (*Result, error) {
-	runes := []rune(p.args[p.optind])
-	c := runes[p.subopt]
-	option := findShort(p.options, c)
+func (p *Parser) short() (Result, bool, error) {
+	arg := p.args[p.optind]
+	c, size := utf8.DecodeRuneInString(arg[p.subopt:])
+	option := p.findShort(c)
 	if option == nil {
-		return nil, Error{Option{"", c, 0}, ErrInvalid}
+		return Result{}, false, Error{Option: Option{Short: c}, Err: ErrInvalid}
 	}
-	switch option.Kind {
+	switch p.kind(option) {
 
 	case KindNone:
-		p.subopt++
-		if p.subopt == len(runes) {
+		p.subopt += size
+		if p.subopt == len(arg) {
 			p.subopt = 0
 			p.optind++
 		}
-		return &Result{*option, ""}, nil
+		return Result{Option: *option}, true, nil
 
 	case KindRequired:
-		optarg := string(runes[p.subopt+1:])
+		optarg := arg[p.subopt+size:]
+		attached := optarg != ""
+		optarg = p.stripEquals(optarg)
 		p.subopt = 0
 		p.optind++
-		if optarg == "" {
+		if !attached {
 			if p.optind == len(p.args) {
-				return nil, Error{*option, ErrMissing}
+				return Result{}, false, Error{Option: *option, Err: ErrMissing}
 			}
 			optarg = p.args[p.optind]
 			p.optind++
 		}
-		return &Result{*option, optarg}, nil
+		return Result{Option: *option, Optarg: optarg, Attached: attached}, true, nil
 
 	case KindOptional:
-		optarg := string(runes[p.subopt+1:])
+		optarg := arg[p.subopt+size:]
+		attached := optarg != ""
+		optarg = p.stripEquals(optarg)
 		p.subopt = 0
 		p.optind++
-		return &Result{*option, optarg}, nil
+		if !attached && !option.Secret {
+			optarg = p.greedy()
+		}
+		return Result{Option: *option, Optarg: optarg, Attached: attached}, true, nil
+
+	case KindUntil:
+		optarg := arg[p.subopt+size:]
+		attached := optarg != ""
+		optarg = p.stripEquals(optarg)
+		p.subopt = 0
+		p.optind++
+		return p.until(option, optarg, attached)
 
 	}
 	panic("invalid Kind")
 }
 
-func (p *parser) long() (*Result, error) {
+// until consumes the arguments of a KindUntil option through its
+// terminator, beginning with an attached argument, if any.
+func (p *Parser) until(option *Option, optarg string, attached bool) (Result, bool, error) {
+	result := Result{Option: *option, Attached: attached}
+	if attached {
+		result.Optargs = append(result.Optargs, optarg)
+	}
+	for ; p.optind < len(p.args); p.optind++ {
+		if p.args[p.optind] == option.until() {
+			p.optind++
+			return result, true, nil
+		}
+		result.Optargs = append(result.Optargs, p.args[p.optind])
+	}
+	return Result{}, false, Error{Option: *option, Err: ErrMissing}
+}
+
+// until returns the effective terminator of a KindUntil option.
+func (o Option) until() string {
+	if o.Until == "" {
+		return ";"
+	}
+	return o.Until
+}
+
+// invalid reports whether err is an unrecognized option on the command
+// line, as opposed to an error wrapping one, such as from a config file.
+func invalid(err error) bool {
+	e, ok := err.(Error)
+	return ok && e.Err == ErrInvalid
+}
+
+// skip moves past the option that caused an error. Only invalid options
+// are left unconsumed, so other errors require no action.
+func (p *Parser) skip(err error) {
+	if !invalid(err) {
+		return
+	}
+	arg := p.args[p.optind]
+	if p.subopt == 0 && strings.HasPrefix(arg, "-") {
+		p.optind++
+		return
+	}
+	_, size := utf8.DecodeRuneInString(arg[p.subopt:])
+	p.subopt += size
+	if p.subopt == len(arg) {
+		p.subopt = 0
+		p.optind++
+	}
+}
+
+// stripEquals removes a leading '=' from an attached short option
+// argument when so configured.
+func (p *Parser) stripEquals(optarg string) string {
+	if p.ShortEquals && strings.HasPrefix(optarg, "=") {
+		return optarg[1:]
+	}
+	return optarg
+}
+
+// kind returns how the option's argument is parsed, where a Secret
+// option does not take a separate argument unless allowed.
+func (p *Parser) kind(option *Option) Kind {
+	if option.Secret && !p.SecretArgs && option.Kind == KindRequired {
+		return KindOptional
+	}
+	return option.Kind
+}
+
+// greedy consumes and returns the next argument as an optional argument
+// if so configured and if it looks like an argument.
+func (p *Parser) greedy() string {
+	if !p.GreedyOptional || p.optind == len(p.args) {
+		return ""
+	}
+	arg := p.args[p.optind]
+	if strings.HasPrefix(arg, "-") || arg == p.terminator() || slices.Contains(p.StopAt, arg) {
+		return ""
+	}
+	p.optind++
+	return arg
+}
+
+func (p *Parser) long() (Result, bool, error) {
 	long := p.args[p.optind][2:]
 
 	eq := strings.IndexByte(long, '=')
@@ -152,48 +787,213 @@ func (p *parser) long() (*Result, error) {
 		attached = true
 	}
 
-	option := findLong(p.options, long)
+	if long == "" {
+		return Result{}, false, Error{Err: ErrInvalid} // such as "--=x"
+	}
+	option := p.findLong(long)
 	if option == nil {
-		return nil, Error{Option{long, 0, 0}, ErrInvalid}
+		var names []string
+		for _, option := range p.options {
+			names = append(names, option.Long)
+		}
+		return Result{}, false, Error{
+			Option:      Option{Long: long},
+			Err:         ErrInvalid,
+			Suggestions: Suggest(long, names),
+		}
 	}
 	p.optind++
 
-	switch option.Kind {
+	switch p.kind(option) {
 
 	case KindNone:
 		if attached {
-			return nil, Error{*option, ErrTooMany}
+			return Result{}, false, Error{Option: *option, Err: ErrTooMany}
 		}
-		return &Result{*option, ""}, nil
+		return Result{Option: *option}, true, nil
 
 	case KindRequired:
-		if p.optind == len(p.args) {
-			return nil, Error{*option, ErrMissing}
-		}
 		if !attached {
+			if p.optind == len(p.args) {
+				return Result{}, false, Error{Option: *option, Err: ErrMissing}
+			}
 			optarg = p.args[p.optind]
 			p.optind++
 		}
-		return &Result{*option, optarg}, nil
+		return Result{Option: *option, Optarg: optarg, Attached: attached}, true, nil
 
 	case KindOptional:
-		return &Result{*option, optarg}, nil
+		if !attached && !option.Secret {
+			optarg = p.greedy()
+		}
+		return Result{Option: *option, Optarg: optarg, Attached: attached}, true, nil
+
+	case KindUntil:
+		return p.until(option, optarg, attached)
 
 	}
 	panic("invalid Kind")
 }
 
-// Next returns the next option in the argument slice. When no arguments
-// remain, returns nil as the result.
-//
-// If there is an error, the associated argument is not consumed.
-func (p *parser) next() (*Result, error) {
+// NewParser returns a Parser over args with the given options. As with
+// Parse, args[0] is skipped.
+func NewParser(options []Option, args []string) *Parser {
+	p := &Parser{options: options, args: args}
+	if len(options) > indexThreshold {
+		p.longs, p.shorts = index(options)
+	}
+	return p
+}
+
+// Reset prepares the Parser to parse a new command line, keeping its
+// Config. Internal lookup tables are reused, so a long-lived Parser
+// does not reallocate them for each command line.
+func (p *Parser) Reset(options []Option, args []string) {
+	p.args = args
+	p.optind, p.subopt, p.expanded = 0, 0, 0
+	p.loads = nil
+	p.setOptions(options)
+}
+
+// setOptions switches to a new option table, continuing from the
+// current position.
+func (p *Parser) setOptions(options []Option) {
+	p.options = options
+	p.done, p.terminated = false, false
+	if len(options) <= indexThreshold {
+		p.longs, p.shorts = nil, nil
+		return
+	}
+	if p.longs == nil {
+		p.longs, p.shorts = index(options)
+		return
+	}
+	clear(p.longs)
+	clear(p.shorts)
+	fill(p.longs, p.shorts, options)
+}
+
+// Next returns the next option in the argument slice. When no options
+// remain, returns false. After an error, parsing may continue with the
+// following option.
+func (p *Parser) Next() (Result, bool, error) {
+	result, ok, err := p.next()
+	p.skip(err)
+	return result, ok, err
+}
+
+// Peek returns what the next call to Next would return without
+// consuming it. When it reports no further options, Rest holds the
+// remaining arguments, allowing a decision such as whether the next
+// argument is a subcommand before committing to it.
+func (p *Parser) Peek() (Result, bool, error) {
+	optind, subopt, warn := p.optind, p.subopt, p.Warn
+	p.Warn = nil // warn and load only once consumed
+	p.peeking = true
+	result, ok, err := p.next()
+	p.Warn, p.peeking = warn, false
+	if !p.done {
+		p.optind, p.subopt = optind, subopt
+	}
+	return result, ok, err
+}
+
+// Inject inserts tokens at the current position so that they are parsed
+// next, for expanding aliases, @file references, and the like without
+// restarting. While in the middle of a cluster of short options, the
+// tokens follow the cluster. Tokens injected once parsing has stopped
+// become part of Rest. The caller's argument slice is not modified.
+func (p *Parser) Inject(tokens ...string) {
+	at := p.Index()
+	if p.subopt > 0 {
+		at++
+	}
+	args := make([]string, 0, len(p.args)+len(tokens))
+	args = append(args, p.args[:at]...)
+	args = append(args, tokens...)
+	p.args = append(args, p.args[at:]...)
+	if at < p.expanded {
+		p.expanded += len(tokens)
+	}
+}
+
+// Index returns the position in args of the next argument to be parsed.
+// Once Next returns false, it is where the remaining arguments begin, so
+// that Rest is equivalent to args[p.Index():].
+func (p *Parser) Index() int {
+	if p.optind == 0 {
+		return 1
+	}
+	return p.optind
+}
+
+// next is Next, except that if there is an error, an invalid option is
+// not consumed.
+func (p *Parser) next() (Result, bool, error) {
 	if p.optind == 0 {
 		p.optind = 1 // initialize
 	}
+	if p.done {
+		return Result{}, false, nil
+	}
+
+	start := p.optind
+	result, ok, err := p.scan()
+	p.done = !ok && err == nil
+	var arg string // the argument, with any Sensitive argument masked
+	if start < len(p.args) {
+		arg = p.cover(start)
+	}
+	if ok {
+		end := p.optind
+		if end == start {
+			end++ // within a cluster of short options
+		}
+		result.Index = start
+		result.Raw = p.args[start:end:end]
+		result.Negated = p.Plus && p.args[start][0] == '+'
+		if result.Sensitive {
+			result.Raw = conceal(result)
+			arg = result.Raw[0]
+		} else if arg != result.Raw[0] {
+			result.Raw = slices.Clone(result.Raw)
+			result.Raw[0] = arg // shares a cluster with a Sensitive option
+		}
+		if serr := p.secret(&result); serr != nil {
+			err = Error{Option: result.Option, Err: serr}
+			result, ok = Result{}, false
+		} else if cerr := check(&result); cerr != nil {
+			err = Error{Option: result.Option, Err: cerr}
+			result, ok = Result{}, false
+		} else if result.Load && !p.peeking {
+			if lerr := p.load(start, result.Optarg); lerr != nil {
+				err = Error{Option: result.Option, Err: lerr}
+				result, ok = Result{}, false
+			}
+		}
+	}
+	if e, ok := err.(Error); ok {
+		e.Index = start
+		e.Arg = arg
+		e.format = p.Format
+		err = e
+	}
+	if ok && result.Deprecated != "" && p.Warn != nil {
+		p.Warn(Error{
+			Option: result.Option,
+			Err:    ErrDeprecated,
+			Index:  start,
+			Arg:    arg,
+			format: p.Format,
+		})
+	}
+	return result, ok, err
+}
 
+// scan parses the option at the current position.
+func (p *Parser) scan() (Result, bool, error) {
 	if p.optind == len(p.args) {
-		return nil, nil
+		return Result{}, false, nil
 	}
 	arg := p.args[p.optind]
 
@@ -202,37 +1002,156 @@ func (p *parser) next() (*Result, error) {
 		return p.short()
 	}
 
+	if p.Bundled && p.optind == 1 && arg != "" && arg[0] != '-' {
+		if err := p.unbundle(); err != nil {
+			return Result{}, false, err
+		}
+		return p.scan()
+	}
+
+	if slices.Contains(p.StopAt, arg) {
+		return Result{}, false, nil
+	}
+
+	if _, ok := p.Aliases[arg]; ok && p.optind >= p.expanded {
+		if err := p.expand(); err != nil {
+			return Result{}, false, err
+		}
+		return p.scan()
+	}
+
+	if arg == p.terminator() {
+		p.optind++
+		p.terminated = true
+		return Result{}, false, nil
+	}
+
+	if p.Plus && len(arg) > 1 && arg[0] == '+' {
+		p.subopt = 1
+		return p.short()
+	}
+
+	if arg != "" && arg[0] != '-' && slices.Contains(p.Dashless, p.optind) {
+		return p.short() // a cluster starting at subopt 0
+	}
+
 	if len(arg) < 2 || arg[0] != '-' {
-		return nil, nil
+		return Result{}, false, nil
 	}
 
 	if arg == "--" {
-		p.optind++
-		return nil, nil
+		return Result{}, false, nil // an operand when not the terminator
 	}
 
 	if arg[:2] == "--" {
 		return p.long()
 	}
+
+	if p.Numeric != nil && isDigits(arg[1:]) {
+		p.optind++
+		return Result{Option: *p.Numeric, Optarg: arg[1:], Attached: true}, true, nil
+	}
+
+	if p.NegativeNumbers && isNegative(arg) &&
+		p.findShort(rune(arg[1])) == nil {
+		return Result{}, false, nil
+	}
+
 	p.subopt = 1
 	return p.short()
 }
 
-// Args slices the argument slice to return the arguments that were not
-// parsed, excluding the "--".
-func (p *parser) rest() []string {
-	return p.args[p.optind:]
+// terminator returns the effective end-of-options marker.
+func (p *Parser) terminator() string {
+	if p.Terminator == "" {
+		return "--"
+	}
+	return p.Terminator
+}
+
+// Rest slices the argument slice to return the arguments that were not
+// parsed, excluding the terminator.
+func (p *Parser) Rest() []string {
+	return p.args[p.Index():]
+}
+
+// isDigits reports whether s is non-empty and consists only of digits.
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// isNegative reports whether an argument looks like a negative number.
+func isNegative(arg string) bool {
+	digit := func(c byte) bool { return c >= '0' && c <= '9' }
+	if !digit(arg[1]) && !(arg[1] == '.' && len(arg) > 2 && digit(arg[2])) {
+		return false
+	}
+	_, err := strconv.ParseFloat(arg, 64)
+	return err == nil || errors.Is(err, strconv.ErrRange)
+}
+
+func (p *Parser) findLong(long string) *Option {
+	var option *Option
+	if p.longs != nil {
+		option = p.longs[long]
+	} else {
+		option = findLong(p.options, long)
+	}
+	if option == nil {
+		option = findPrefix(p.options, long)
+	}
+	if option == nil && p.Resolver != nil {
+		option = p.Resolver.ResolveLong(long)
+	}
+	return option
+}
+
+func (p *Parser) findShort(short rune) *Option {
+	var option *Option
+	if p.shorts != nil {
+		option = p.shorts[short]
+	} else {
+		option = findShort(p.options, short)
+	}
+	if option == nil && p.Resolver != nil {
+		option = p.Resolver.ResolveShort(short)
+	}
+	return option
 }
 
 func findLong(options []Option, long string) *Option {
 	for i, option := range options {
-		if option.Long == long {
+		if option.Long != "" && option.Long == long && !option.Prefix {
 			return &options[i]
 		}
 	}
 	return nil
 }
 
+// findPrefix returns a copy of the Prefix option with the longest prefix
+// of long, named long.
+func findPrefix(options []Option, long string) *Option {
+	var match *Option
+	for i, option := range options {
+		if option.Prefix && len(long) > len(option.Long) &&
+			strings.HasPrefix(long, option.Long) &&
+			(match == nil || len(option.Long) > len(match.Long)) {
+			match = &options[i]
+		}
+	}
+	if match == nil {
+		return nil
+	}
+	option := *match
+	option.Long = long
+	return &option
+}
+
 func findShort(options []Option, short rune) *Option {
 	for i, option := range options {
 		if option.Short != 0 && option.Short == short {
@@ -1,21 +1,27 @@
 package optparse
 
 import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
 	"strconv"
+	"strings"
 	"testing"
 )
 
 var options = []Option{
-	{"amend", 'a', KindNone},
-	{"brief", 'b', KindNone},
-	{"color", 'c', KindOptional},
-	{"delay", 'd', KindRequired},
-	{"erase", 'e', KindNone},
+	{Long: "amend", Short: 'a', Kind: KindNone},
+	{Long: "brief", Short: 'b', Kind: KindNone},
+	{Long: "color", Short: 'c', Kind: KindOptional},
+	{Long: "delay", Short: 'd', Kind: KindRequired},
+	{Long: "erase", Short: 'e', Kind: KindNone},
 
 	// special cases
-	{"pi", 'π', KindNone}, // multibyte short option
-	{"long", 0, KindNone}, // long only
-	{"", 's', KindNone},   // short only
+	{Long: "pi", Short: 'π', Kind: KindNone}, // multibyte short option
+	{Long: "long", Kind: KindNone},           // long only
+	{Short: 's', Kind: KindNone},             // short only
 }
 
 type config struct {
@@ -27,9 +33,13 @@ type config struct {
 	pi    int
 }
 
-func parse(args []string) (conf config, rest []string, err error) {
+func parse(args []string) (config, []string, error) {
+	return parseWith(Config{}, args)
+}
+
+func parseWith(cfg Config, args []string) (conf config, rest []string, err error) {
 	var results []Result
-	results, rest, err = Parse(options, args)
+	results, rest, err = ParseWith(cfg, options, args)
 	for _, result := range results {
 		switch result.Long {
 		case "amend":
@@ -128,19 +138,19 @@ func TestParse(t *testing.T) {
 			[]string{"", "--delay"},
 			config{false, false, "", 0, 0, 0},
 			[]string{},
-			Error{Option{"delay", 'd', KindRequired}, ErrMissing},
+			Error{Option: Option{Long: "delay", Short: 'd', Kind: KindRequired}, Err: ErrMissing, Index: 1, Arg: "--delay"},
 		},
 		{
 			[]string{"", "--foo", "bar"},
 			config{false, false, "", 0, 0, 0},
 			[]string{"--foo", "bar"},
-			Error{Option{"foo", 0, 0}, ErrInvalid},
+			Error{Option: Option{Long: "foo"}, Err: ErrInvalid, Index: 1, Arg: "--foo"},
 		},
 		{
 			[]string{"", "-x"},
 			config{false, false, "", 0, 0, 0},
 			[]string{"-x"},
-			Error{Option{"", 'x', 0}, ErrInvalid},
+			Error{Option: Option{Short: 'x'}, Err: ErrInvalid, Index: 1, Arg: "-x"},
 		},
 		{
 			[]string{"", "-"},
@@ -152,7 +162,7 @@ func TestParse(t *testing.T) {
 			[]string{"", "-\x00"},
 			config{false, false, "", 0, 0, 0},
 			[]string{"-\x00"},
-			Error{Option{"", 0, 0}, ErrInvalid},
+			Error{Option: Option{}, Err: ErrInvalid, Index: 1, Arg: "-\x00"},
 		},
 	}
 
@@ -168,10 +178,909 @@ func TestParse(t *testing.T) {
 			want := row.err.(Error)
 			if err == nil {
 				t.Errorf("parse(%q), got nil, wanted %#v", row.args[1:], want)
-			} else if got := err.(Error); got != want {
+			} else if got := err.(Error); !reflect.DeepEqual(got, want) {
 				t.Errorf("parse(%q), got %#v, wanted %#v",
 					row.args[1:], got, want)
 			}
 		}
 	}
 }
+
+func TestTerminator(t *testing.T) {
+	table := []struct {
+		terminator string
+		args       []string
+		conf       config
+		rest       []string
+		err        string
+	}{
+		{"", []string{"", "-a", "--", "-b"}, config{amend: true}, []string{"-b"}, ""},
+		{";", []string{"", "-a", ";", "-b"}, config{amend: true}, []string{"-b"}, ""},
+		{";", []string{"", "-a", "--", "-b"}, config{amend: true}, []string{"--", "-b"}, ""},
+		{"::", []string{"", "::", "--", "-a"}, config{}, []string{"--", "-a"}, ""},
+		{"--end", []string{"", "--end", "-a"}, config{}, []string{"-a"}, ""},
+		{";", []string{"", "-a", "--=x"}, config{amend: true}, []string{"--=x"}, "invalid option: --"},
+		{"", []string{"", "-a", "--=x"}, config{amend: true}, []string{"--=x"}, "invalid option: --"},
+	}
+
+	for _, row := range table {
+		cfg := Config{Terminator: row.terminator}
+		conf, rest, err := parseWith(cfg, row.args)
+		if got := fmt.Sprint(err); row.err == "" && err != nil || row.err != "" && got != row.err {
+			t.Errorf("parse(%q), got error %v, want %q", row.args[1:], err, row.err)
+		}
+		if conf != row.conf {
+			t.Errorf("parse(%q), got %v, want %v", row.args[1:], conf, row.conf)
+		}
+		if !equal(rest, row.rest) {
+			t.Errorf("parse(%q), got %v, want %v", row.args[1:], rest, row.rest)
+		}
+	}
+}
+
+func TestStopAt(t *testing.T) {
+	table := []struct {
+		args []string
+		conf config
+		rest []string
+	}{
+		{[]string{"", "-a", "(", "-b", ")"}, config{amend: true}, []string{"(", "-b", ")"}},
+		{[]string{"", "-a", "!", "-b"}, config{amend: true}, []string{"!", "-b"}},
+		{[]string{"", "-d", "(", "-b"}, config{delay: 0, brief: true}, nil},
+		{[]string{"", "-c", "!"}, config{}, []string{"!"}},
+		{[]string{"", "-a", "--", "("}, config{amend: true}, []string{"("}},
+	}
+
+	for _, row := range table {
+		cfg := Config{StopAt: []string{"(", "!"}, GreedyOptional: true}
+		conf, rest, _ := parseWith(cfg, row.args)
+		if conf != row.conf {
+			t.Errorf("parse(%q), got %v, want %v", row.args[1:], conf, row.conf)
+		}
+		if !equal(rest, row.rest) {
+			t.Errorf("parse(%q), got %v, want %v", row.args[1:], rest, row.rest)
+		}
+	}
+}
+
+func TestNegativeNumbers(t *testing.T) {
+	table := []struct {
+		args []string
+		conf config
+		rest []string
+		err  error
+	}{
+		{[]string{"", "-a", "-5"}, config{amend: true}, []string{"-5"}, nil},
+		{[]string{"", "-3.14", "-a"}, config{}, []string{"-3.14", "-a"}, nil},
+		{[]string{"", "-.5"}, config{}, []string{"-.5"}, nil},
+		{[]string{"", "-1e400"}, config{}, []string{"-1e400"}, nil},
+		{[]string{"", "-5x"}, config{}, []string{"-5x"},
+			Error{Option: Option{Short: '5'}, Err: ErrInvalid, Index: 1, Arg: "-5x"}},
+		{[]string{"", "-d", "-5"}, config{delay: -5}, []string{}, nil},
+	}
+
+	cfg := Config{NegativeNumbers: true}
+	for _, row := range table {
+		conf, rest, err := parseWith(cfg, row.args)
+		if conf != row.conf {
+			t.Errorf("parse(%q), got %v, want %v", row.args[1:], conf, row.conf)
+		}
+		if !equal(rest, row.rest) {
+			t.Errorf("parse(%q), got %v, want %v", row.args[1:], rest, row.rest)
+		}
+		if !reflect.DeepEqual(err, row.err) {
+			t.Errorf("parse(%q), got %#v, want %#v", row.args[1:], err, row.err)
+		}
+	}
+}
+
+func TestNumeric(t *testing.T) {
+	table := []struct {
+		args []string
+		conf config
+		rest []string
+		err  error
+	}{
+		{[]string{"", "-20", "foo"}, config{delay: 20}, []string{"foo"}, nil},
+		{[]string{"", "-a", "-5", "-d", "7"}, config{amend: true, delay: 7}, []string{}, nil},
+		{[]string{"", "-a5"}, config{amend: true}, []string{"-a5"},
+			Error{Option: Option{Short: '5'}, Err: ErrInvalid, Index: 1, Arg: "-a5"}},
+		{[]string{"", "-5.0"}, config{}, []string{"-5.0"},
+			Error{Option: Option{Short: '5'}, Err: ErrInvalid, Index: 1, Arg: "-5.0"}},
+	}
+
+	cfg := Config{Numeric: &options[3]}
+	for _, row := range table {
+		conf, rest, err := parseWith(cfg, row.args)
+		if conf != row.conf {
+			t.Errorf("parse(%q), got %v, want %v", row.args[1:], conf, row.conf)
+		}
+		if !equal(rest, row.rest) {
+			t.Errorf("parse(%q), got %v, want %v", row.args[1:], rest, row.rest)
+		}
+		if !reflect.DeepEqual(err, row.err) {
+			t.Errorf("parse(%q), got %#v, want %#v", row.args[1:], err, row.err)
+		}
+	}
+}
+
+func TestShortEquals(t *testing.T) {
+	table := []struct {
+		cfg  Config
+		args []string
+		conf config
+	}{
+		{Config{}, []string{"", "-c=red"}, config{color: "=red"}},
+		{Config{ShortEquals: true}, []string{"", "-c=red"}, config{color: "red"}},
+		{Config{ShortEquals: true}, []string{"", "-ac=red"}, config{amend: true, color: "red"}},
+		{Config{ShortEquals: true}, []string{"", "-d=10"}, config{delay: 10}},
+		{Config{ShortEquals: true}, []string{"", "-d", "=10"}, config{}},
+		{Config{ShortEquals: true}, []string{"", "-c==x"}, config{color: "=x"}},
+	}
+
+	for _, row := range table {
+		conf, _, err := parseWith(row.cfg, row.args)
+		if err != nil {
+			t.Errorf("parse(%q), got %v", row.args[1:], err)
+		}
+		if conf != row.conf {
+			t.Errorf("parse(%q), got %v, want %v", row.args[1:], conf, row.conf)
+		}
+	}
+}
+
+func TestGreedyOptional(t *testing.T) {
+	table := []struct {
+		terminator string
+		args       []string
+		conf       config
+		rest       []string
+	}{
+		{"", []string{"", "--color", "red", "foo"}, config{color: "red"}, []string{"foo"}},
+		{"", []string{"", "-c", "red"}, config{color: "red"}, []string{}},
+		{"", []string{"", "-ac", "red"}, config{amend: true, color: "red"}, []string{}},
+		{"", []string{"", "-cblue", "red"}, config{color: "blue"}, []string{"red"}},
+		{"", []string{"", "--color=", "red"}, config{}, []string{"red"}},
+		{"", []string{"", "--color", "-a"}, config{amend: true}, []string{}},
+		{"", []string{"", "--color", "-"}, config{}, []string{"-"}},
+		{"", []string{"", "--color", "--", "red"}, config{}, []string{"red"}},
+		{";", []string{"", "--color", ";", "red"}, config{}, []string{"red"}},
+		{"", []string{"", "--color"}, config{}, []string{}},
+	}
+
+	for _, row := range table {
+		cfg := Config{Terminator: row.terminator, GreedyOptional: true}
+		conf, rest, err := parseWith(cfg, row.args)
+		if err != nil {
+			t.Errorf("parse(%q), got %v", row.args[1:], err)
+		}
+		if conf != row.conf {
+			t.Errorf("parse(%q), got %v, want %v", row.args[1:], conf, row.conf)
+		}
+		if !equal(rest, row.rest) {
+			t.Errorf("parse(%q), got %v, want %v", row.args[1:], rest, row.rest)
+		}
+	}
+}
+
+func TestAttached(t *testing.T) {
+	table := []struct {
+		args     []string
+		attached []bool
+	}{
+		{[]string{"", "-a", "-d10", "-d", "10"}, []bool{false, true, false}},
+		{[]string{"", "--delay=10", "--delay", "10"}, []bool{true, false}},
+		{[]string{"", "-a", "--delay=10"}, []bool{false, true}},
+		{[]string{"", "--color", "--color="}, []bool{false, true}},
+		{[]string{"", "-c", "-cred", "-ac"}, []bool{false, true, false, false}},
+	}
+
+	for _, row := range table {
+		results, _, err := Parse(options, row.args)
+		if err != nil {
+			t.Errorf("Parse(%q), got %v", row.args[1:], err)
+		}
+		var attached []bool
+		for _, result := range results {
+			attached = append(attached, result.Attached)
+		}
+		if fmt.Sprint(attached) != fmt.Sprint(row.attached) {
+			t.Errorf("Parse(%q), got %v, want %v", row.args[1:], attached, row.attached)
+		}
+	}
+}
+
+func TestPositions(t *testing.T) {
+	args := []string{"", "-ab", "--delay", "10", "-cred", "--color", "-d5", "x"}
+	want := []struct {
+		index int
+		raw   []string
+	}{
+		{1, []string{"-ab"}},
+		{1, []string{"-ab"}},
+		{2, []string{"--delay", "10"}},
+		{4, []string{"-cred"}},
+		{5, []string{"--color"}},
+		{6, []string{"-d5"}},
+	}
+
+	results, _, err := Parse(options, args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != len(want) {
+		t.Fatalf("Parse(%q), got %d results, want %d", args[1:], len(results), len(want))
+	}
+	for i, result := range results {
+		if result.Index != want[i].index || !equal(result.Raw, want[i].raw) {
+			t.Errorf("Parse(%q)[%d], got %d %q, want %d %q", args[1:], i,
+				result.Index, result.Raw, want[i].index, want[i].raw)
+		}
+	}
+}
+
+func TestErrorPosition(t *testing.T) {
+	table := []struct {
+		args  []string
+		index int
+		arg   string
+	}{
+		{[]string{"", "-a", "-bx"}, 2, "-bx"},
+		{[]string{"", "-a", "--amend=yes"}, 2, "--amend=yes"},
+		{[]string{"", "-a", "-ed"}, 2, "-ed"},
+	}
+
+	for _, row := range table {
+		_, _, err := Parse(options, row.args)
+		e, ok := err.(Error)
+		if !ok {
+			t.Errorf("Parse(%q), got %#v, want Error", row.args[1:], err)
+		} else if e.Index != row.index || e.Arg != row.arg {
+			t.Errorf("Parse(%q), got %d %q, want %d %q",
+				row.args[1:], e.Index, e.Arg, row.index, row.arg)
+		}
+	}
+}
+
+func TestErrorIs(t *testing.T) {
+	table := []struct {
+		args []string
+		want error
+	}{
+		{[]string{"", "-x"}, ErrInvalid},
+		{[]string{"", "--delay"}, ErrMissing},
+		{[]string{"", "--amend=yes"}, ErrTooMany},
+	}
+
+	for _, row := range table {
+		_, _, err := Parse(options, row.args)
+		if !errors.Is(err, row.want) {
+			t.Errorf("Parse(%q), got %v, want %v", row.args[1:], err, row.want)
+		}
+	}
+}
+
+func TestAllErrors(t *testing.T) {
+	table := []struct {
+		args []string
+		conf config
+		rest []string
+		errs []error
+	}{
+		{[]string{"", "-a"}, config{amend: true}, []string{}, nil},
+		{
+			[]string{"", "-xay", "--foo", "-b", "--amend=1", "-e", "x"},
+			config{amend: true, brief: true, erase: 1},
+			[]string{"x"},
+			[]error{
+				Error{Option: Option{Short: 'x'}, Err: ErrInvalid, Index: 1, Arg: "-xay"},
+				Error{Option: Option{Short: 'y'}, Err: ErrInvalid, Index: 1, Arg: "-xay"},
+				Error{Option: Option{Long: "foo"}, Err: ErrInvalid, Index: 2, Arg: "--foo"},
+				Error{Option: options[0], Err: ErrTooMany, Index: 4, Arg: "--amend=1"},
+			},
+		},
+		{
+			[]string{"", "-b", "-d"},
+			config{brief: true},
+			[]string{},
+			[]error{Error{Option: options[3], Err: ErrMissing, Index: 2, Arg: "-d"}},
+		},
+	}
+
+	cfg := Config{AllErrors: true}
+	for _, row := range table {
+		conf, rest, err := parseWith(cfg, row.args)
+		if conf != row.conf {
+			t.Errorf("parse(%q), got %v, want %v", row.args[1:], conf, row.conf)
+		}
+		if !equal(rest, row.rest) {
+			t.Errorf("parse(%q), got %v, want %v", row.args[1:], rest, row.rest)
+		}
+		if row.errs == nil {
+			if err != nil {
+				t.Errorf("parse(%q), got %v", row.args[1:], err)
+			}
+			continue
+		}
+		joined, ok := err.(interface{ Unwrap() []error })
+		if !ok {
+			t.Errorf("parse(%q), got %#v, want joined errors", row.args[1:], err)
+			continue
+		}
+		errs := joined.Unwrap()
+		if fmt.Sprintf("%#v", errs) != fmt.Sprintf("%#v", row.errs) {
+			t.Errorf("parse(%q), got %#v, want %#v", row.args[1:], errs, row.errs)
+		}
+	}
+}
+
+func TestLenient(t *testing.T) {
+	var warnings []string
+	cfg := Config{
+		Lenient: true,
+		Warn:    func(err error) { warnings = append(warnings, err.Error()) },
+	}
+	args := []string{"", "-axb", "--new=value", "--delay", "10", "--new", "foo"}
+	conf, rest, err := parseWith(cfg, args)
+	if err != nil {
+		t.Errorf("parse(%q), got %v", args[1:], err)
+	}
+	want := config{amend: true, brief: true, delay: 10}
+	if conf != want {
+		t.Errorf("parse(%q), got %v, want %v", args[1:], conf, want)
+	}
+	if !equal(rest, []string{"foo"}) {
+		t.Errorf("parse(%q), got %q, want %q", args[1:], rest, []string{"foo"})
+	}
+	wantWarnings := []string{
+		"invalid option: -x",
+		"invalid option: --new",
+		"invalid option: --new",
+	}
+	if !equal(warnings, wantWarnings) {
+		t.Errorf("parse(%q), got %q, want %q", args[1:], warnings, wantWarnings)
+	}
+
+	args = []string{"", "-b", "-d"}
+	_, _, err = parseWith(cfg, args)
+	if !errors.Is(err, ErrMissing) {
+		t.Errorf("parse(%q), got %v, want %v", args[1:], err, ErrMissing)
+	}
+}
+
+func TestFormat(t *testing.T) {
+	messages := map[error]string{
+		ErrInvalid: "option invalide",
+		ErrMissing: "l'option requiert un argument",
+	}
+	cfg := Config{
+		Format: func(e Error) string {
+			if message, ok := messages[e.Err]; ok {
+				return fmt.Sprintf("%s -- %s", message, e.Arg)
+			}
+			return e.Error()
+		},
+	}
+
+	table := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"", "-x"}, "option invalide -- -x"},
+		{[]string{"", "--delay"}, "l'option requiert un argument -- --delay"},
+		{[]string{"", "--amend=1"}, "option takes no arguments: --amend (-a)"},
+	}
+
+	for _, row := range table {
+		_, _, err := ParseWith(cfg, options, row.args)
+		if err == nil || err.Error() != row.want {
+			t.Errorf("ParseWith(%q), got %v, want %q", row.args[1:], err, row.want)
+		}
+	}
+}
+
+func TestMustParse(t *testing.T) {
+	results, rest := MustParse(options, []string{"", "-a", "x"})
+	if len(results) != 1 || !equal(rest, []string{"x"}) {
+		t.Errorf("MustParse(), got %v %q", results, rest)
+	}
+
+	defer func() {
+		if !errors.Is(recover().(error), ErrInvalid) {
+			t.Errorf("MustParse(), wanted panic with %v", ErrInvalid)
+		}
+	}()
+	MustParse(options, []string{"", "-x"})
+}
+
+func TestParseArgs(t *testing.T) {
+	old := os.Args
+	defer func() { os.Args = old }()
+	os.Args = []string{"prog", "-ab", "--", "x"}
+
+	results, rest, err := ParseArgs(options)
+	if err != nil || len(results) != 2 || !equal(rest, []string{"x"}) {
+		t.Errorf("ParseArgs(), got %v %q %v", results, rest, err)
+	}
+}
+
+func TestConfigCombined(t *testing.T) {
+	var warnings int
+	cfg := Config{
+		Terminator:      ";",
+		NegativeNumbers: true,
+		ShortEquals:     true,
+		GreedyOptional:  true,
+		AllErrors:       true,
+		Lenient:         true,
+		Warn:            func(error) { warnings++ },
+	}
+	args := []string{"", "-xc=red", "-d", "-3", "--color", "blue", "--amend=1", ";", "-5"}
+	conf, rest, err := parseWith(cfg, args)
+	want := config{color: "blue", delay: -3}
+	if conf != want {
+		t.Errorf("parse(%q), got %v, want %v", args[1:], conf, want)
+	}
+	if !equal(rest, []string{"-5"}) {
+		t.Errorf("parse(%q), got %q, want %q", args[1:], rest, []string{"-5"})
+	}
+	if !errors.Is(err, ErrTooMany) {
+		t.Errorf("parse(%q), got %v, want %v", args[1:], err, ErrTooMany)
+	}
+	if warnings != 1 {
+		t.Errorf("parse(%q), got %d warnings, want 1", args[1:], warnings)
+	}
+}
+
+func TestRun(t *testing.T) {
+	var conf config
+	errBusy := errors.New("busy")
+	options := []Option{
+		{Long: "amend", Short: 'a', Kind: KindNone,
+			Func: func(string) error { conf.amend = true; return nil }},
+		{Long: "brief", Short: 'b', Kind: KindNone},
+		{Long: "color", Short: 'c', Kind: KindOptional,
+			Func: func(optarg string) error { conf.color = optarg; return nil }},
+		{Long: "delay", Short: 'd', Kind: KindRequired, Type: TypeInt,
+			Func: func(optarg string) error {
+				conf.delay, _ = strconv.Atoi(optarg)
+				return nil
+			}},
+		{Long: "erase", Short: 'e', Kind: KindNone,
+			Func: func(string) error { return errBusy }},
+	}
+
+	rest, err := Run(options, []string{"", "-ab", "--color=red", "-d", "5", "x"})
+	want := config{amend: true, color: "red", delay: 5}
+	if err != nil || conf != want || !equal(rest, []string{"x"}) {
+		t.Errorf("Run(), got %v %q %v, want %v", conf, rest, err, want)
+	}
+
+	conf = config{}
+	rest, err = Run(options, []string{"", "-a", "-ec", "x"})
+	var e Error
+	if !errors.Is(err, errBusy) || !errors.As(err, &e) || e.Long != "erase" || e.Arg != "-ec" {
+		t.Errorf("Run(), got %#v, want %v", err, errBusy)
+	}
+	if conf.color != "" || !conf.amend || !equal(rest, []string{"-ec", "x"}) {
+		t.Errorf("Run(), got %v %q", conf, rest)
+	}
+
+	_, err = Run(options, []string{"", "-d", "x"})
+	if err == nil {
+		t.Errorf("Run(), got nil, want conversion error")
+	}
+}
+
+func TestRequired(t *testing.T) {
+	options := []Option{
+		{Long: "amend", Short: 'a', Kind: KindNone},
+		{Long: "delay", Short: 'd', Kind: KindRequired, Required: true},
+		{Short: 's', Kind: KindNone, Required: true},
+	}
+
+	if _, _, err := Parse(options, []string{"", "-sd1"}); err != nil {
+		t.Errorf("Parse(), got %v", err)
+	}
+
+	_, _, err := Parse(options, []string{"", "-a"})
+	joined, ok := err.(interface{ Unwrap() []error })
+	if !ok || len(joined.Unwrap()) != 2 {
+		t.Fatalf("Parse(), got %#v, want two errors", err)
+	}
+	want := "missing required option: --delay (-d)\nmissing required option: -s"
+	if err.Error() != want {
+		t.Errorf("Parse(), got %q, want %q", err, want)
+	}
+
+	_, _, err = Parse(options, []string{"", "-x"})
+	if !errors.Is(err, ErrInvalid) || errors.Is(err, ErrRequired) {
+		t.Errorf("Parse(), got %v, want only %v", err, ErrInvalid)
+	}
+}
+
+func TestParserIndex(t *testing.T) {
+	table := []struct {
+		args  []string
+		index int
+	}{
+		{[]string{""}, 1},
+		{[]string{"", "-ab", "x"}, 2},
+		{[]string{"", "-d", "1", "--", "x"}, 4},
+		{[]string{"", "--color", "--", "-a"}, 3},
+		{[]string{"", "-x", "-a"}, 3},
+	}
+	for _, row := range table {
+		p := NewParser(options, row.args)
+		for {
+			_, ok, err := p.Next()
+			if err == nil && !ok {
+				break
+			}
+		}
+		if p.Index() != row.index {
+			t.Errorf("Index(%q), got %d, want %d", row.args, p.Index(), row.index)
+		}
+		if want := row.args[row.index:]; !equal(p.Rest(), want) {
+			t.Errorf("Rest(%q), got %q, want %q", row.args, p.Rest(), want)
+		}
+	}
+}
+
+func TestParserReset(t *testing.T) {
+	var large []Option
+	for i := 0; i <= indexThreshold; i++ {
+		large = append(large, Option{Long: fmt.Sprint("opt", i), Kind: KindNone})
+	}
+
+	p := NewParser(options, []string{"", "-a", "x"})
+	p.Terminator = ";"
+	table := []struct {
+		options []Option
+		args    []string
+		want    string
+	}{
+		{large, []string{"", "--opt32", ";", "y"}, "opt32"},
+		{large, []string{"", "--opt0", ";", "y"}, "opt0"},
+		{options, []string{"", "-b", ";", "y"}, "brief"},
+	}
+	for _, row := range table {
+		p.Reset(row.options, row.args)
+		result, ok, err := p.Next()
+		if err != nil || !ok || result.Long != row.want {
+			t.Fatalf("Next(%q), got %v %v %v", row.args, result.Long, ok, err)
+		}
+		if _, ok, _ := p.Next(); ok || !equal(p.Rest(), []string{"y"}) {
+			t.Errorf("Rest(%q), got %q", row.args, p.Rest())
+		}
+	}
+}
+
+func TestParserPeek(t *testing.T) {
+	args := []string{"", "-ad", "1", "-x", "--", "cmd"}
+	p := NewParser(options, args)
+	for _, want := range []string{"amend", "delay", "", ""} {
+		peek, pok, perr := p.Peek()
+		next, nok, nerr := p.Next()
+		if !reflect.DeepEqual(peek, next) || pok != nok || !reflect.DeepEqual(perr, nerr) {
+			t.Errorf("Peek(), got %v %v %v, want %v %v %v",
+				peek.Long, pok, perr, next.Long, nok, nerr)
+		}
+		if next.Long != want {
+			t.Errorf("Next(), got %q, want %q", next.Long, want)
+		}
+	}
+	if _, ok, err := p.Peek(); ok || err != nil || !equal(p.Rest(), []string{"cmd"}) {
+		t.Errorf("Peek(), got %v %v, rest %q", ok, err, p.Rest())
+	}
+	if _, ok, _ := p.Next(); ok || !equal(p.Rest(), []string{"cmd"}) {
+		t.Errorf("Next() after end, got %v, rest %q", ok, p.Rest())
+	}
+}
+
+func TestParserInject(t *testing.T) {
+	args := []string{"", "-@a", "x"}
+	p := NewParser(append(options, Option{Short: '@', Kind: KindNone}), args)
+	var got []string
+	for {
+		result, ok, err := p.Next()
+		if err != nil || !ok {
+			break
+		}
+		got = append(got, result.Option.name())
+		if result.Short == '@' {
+			p.Inject("--delay", "1", "-b")
+		}
+	}
+	want := []string{"@", "amend", "delay", "brief"}
+	if !equal(got, want) || !equal(p.Rest(), []string{"x"}) {
+		t.Errorf("Inject(), got %q %q, want %q", got, p.Rest(), want)
+	}
+	if args[1] != "-@a" || len(args) != 3 {
+		t.Errorf("Inject(), modified args %q", args)
+	}
+
+	p.Inject("y")
+	if !equal(p.Rest(), []string{"y", "x"}) {
+		t.Errorf("Inject() after end, got %q", p.Rest())
+	}
+}
+
+// defines resolves --define-NAME options and -W as a short option.
+type defines struct{}
+
+func (defines) ResolveLong(long string) *Option {
+	if !strings.HasPrefix(long, "define-") {
+		return nil
+	}
+	return &Option{Long: long, Kind: KindOptional}
+}
+
+func (defines) ResolveShort(short rune) *Option {
+	if short != 'W' {
+		return nil
+	}
+	return &Option{Short: 'W', Kind: KindRequired}
+}
+
+func TestResolver(t *testing.T) {
+	cfg := Config{Resolver: defines{}}
+	args := []string{"", "-aWall", "--define-X=1", "--define-Y", "-W", "error", "x"}
+	results, rest, err := ParseWith(cfg, options, args)
+	if err != nil {
+		t.Fatalf("Parse(), got %v", err)
+	}
+	var got []string
+	for _, result := range results {
+		got = append(got, result.Option.name()+"="+result.Optarg)
+	}
+	want := []string{"amend=", "W=all", "define-X=1", "define-Y=", "W=error"}
+	if !equal(got, want) || !equal(rest, []string{"x"}) {
+		t.Errorf("Parse(), got %q %q, want %q", got, rest, want)
+	}
+
+	_, _, err = ParseWith(cfg, options, []string{"", "--undefined"})
+	if !errors.Is(err, ErrInvalid) {
+		t.Errorf("Parse(), got %v, want %v", err, ErrInvalid)
+	}
+}
+
+func TestPrefix(t *testing.T) {
+	options := []Option{
+		{Long: "x-", Kind: KindRequired, Prefix: true},
+		{Long: "x-debug-", Kind: KindNone, Prefix: true},
+		{Long: "x-exact", Kind: KindNone},
+	}
+	table := []struct {
+		arg  string
+		want string
+		err  error
+	}{
+		{"--x-plugin-level=3", "x-plugin-level=3", nil},
+		{"--x-debug-net", "x-debug-net=", nil},
+		{"--x-exact", "x-exact=", nil},
+		{"--x-", "", ErrInvalid},
+		{"--y-plugin=1", "", ErrInvalid},
+	}
+	for _, row := range table {
+		results, _, err := Parse(options, []string{"", row.arg})
+		if !errors.Is(err, row.err) {
+			t.Errorf("Parse(%q), got %v, want %v", row.arg, err, row.err)
+			continue
+		}
+		if err == nil {
+			got := results[0].Long + "=" + results[0].Optarg
+			if got != row.want || !results[0].Prefix && row.arg != "--x-exact" {
+				t.Errorf("Parse(%q), got %q, want %q", row.arg, got, row.want)
+			}
+		}
+	}
+}
+
+func TestDeprecated(t *testing.T) {
+	options := []Option{
+		{Long: "colour", Kind: KindOptional, Deprecated: "use --color instead"},
+		{Long: "color", Short: 'c', Kind: KindOptional},
+	}
+	var warnings []string
+	cfg := Config{Warn: func(err error) {
+		if errors.Is(err, ErrDeprecated) {
+			warnings = append(warnings, err.Error())
+		}
+	}}
+	results, _, err := ParseWith(cfg, options, []string{"", "--colour=auto", "-c", "--colour"})
+	if err != nil || len(results) != 3 || results[0].Deprecated == "" {
+		t.Fatalf("Parse(), got %v %v", results, err)
+	}
+	want := []string{
+		"deprecated option: --colour: use --color instead",
+		"deprecated option: --colour: use --color instead",
+	}
+	if !equal(warnings, want) {
+		t.Errorf("Warn(), got %q, want %q", warnings, want)
+	}
+
+	if _, _, err := Parse(options, []string{"", "--colour"}); err != nil {
+		t.Errorf("Parse(), got %v", err)
+	}
+}
+
+func TestArgName(t *testing.T) {
+	options := []Option{
+		{Long: "delay", Short: 'd', Kind: KindRequired, ArgName: "SECONDS"},
+		{Long: "output", Kind: KindRequired, ArgName: "FILE"},
+	}
+	table := []struct {
+		arg  string
+		want string
+	}{
+		{"-d", "option --delay (-d) requires SECONDS"},
+		{"--output", "option --output requires FILE"},
+	}
+	for _, row := range table {
+		_, _, err := Parse(options, []string{"", row.arg})
+		if got := fmt.Sprint(err); got != row.want {
+			t.Errorf("Parse(%q), got %q, want %q", row.arg, got, row.want)
+		}
+		if !errors.Is(err, ErrMissing) {
+			t.Errorf("Parse(%q), got %v, want %v", row.arg, err, ErrMissing)
+		}
+	}
+}
+
+func TestResultString(t *testing.T) {
+	options := []Option{
+		{Long: "token", Short: 't', Kind: KindRequired, Sensitive: true},
+		{Long: "jobs", Kind: KindRequired},
+		{Short: 'o', Kind: KindRequired},
+		{Short: 'v', Kind: KindNone},
+	}
+	results, _, err := Parse(options, []string{"", "-t", "abc", "--jobs", "4", "-ofile", "-v"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "[--token=**** --jobs=4 -ofile -v]"
+	if got := fmt.Sprint(results); got != want {
+		t.Errorf("Sprint(), got %q, want %q", got, want)
+	}
+}
+
+func TestSensitive(t *testing.T) {
+	options := []Option{
+		{Long: "token", Short: 't', Kind: KindRequired, Sensitive: true, Pattern: regexp.MustCompile(`^[a-z]+$`)},
+		{Long: "pin", Kind: KindRequired, Sensitive: true, Type: TypeInt, EnvVar: "TEST_PIN"},
+		{Short: 'v', Kind: KindNone},
+	}
+	table := []struct {
+		args []string
+		want string
+		arg  string
+	}{
+		{[]string{"", "--token=ABC123"}, "argument \"****\" does not match ^[a-z]+$: --token (-t)", "--token=****"},
+		{[]string{"", "-vtO"}, "argument \"****\" does not match ^[a-z]+$: --token (-t)", "-vt****"},
+		{[]string{"", "--token", "O"}, "argument \"****\" does not match ^[a-z]+$: --token (-t)", "--token"},
+		{[]string{"", "--pin", "12x4"}, "invalid integer \"****\": --pin", "--pin"},
+		{[]string{"", "--pin=o"}, "invalid integer \"****\": --pin", "--pin=****"},
+		{[]string{"", "-xtabc"}, "invalid option: -x", "-xt****"},
+	}
+	for _, row := range table {
+		_, _, err := Parse(options, row.args)
+		var e Error
+		if got := fmt.Sprint(err); got != row.want {
+			t.Errorf("Parse(%q), got %q, want %q", row.args, got, row.want)
+		} else if !errors.As(err, &e) || e.Arg != row.arg {
+			t.Errorf("Parse(%q), got Arg %q, want %q", row.args, e.Arg, row.arg)
+		}
+	}
+
+	args := []string{"", "-tok", "--token", "on", "--pin=1", "-vtok", "-vt", "ok"}
+	results, _, err := Parse(options, args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var raw [][]string
+	for _, result := range results {
+		raw = append(raw, result.Raw)
+	}
+	want := [][]string{
+		{"-t****"}, {"--token", "****"}, {"--pin=****"},
+		{"-vt****"}, {"-vt****"}, {"-vt"}, {"-vt", "****"},
+	}
+	if !reflect.DeepEqual(raw, want) {
+		t.Errorf("Parse(), got Raw %q, want %q", raw, want)
+	}
+	if results[0].Optarg != "ok" || args[5] != "-vtok" {
+		t.Errorf("Parse(), got Optarg %q, args %q", results[0].Optarg, args)
+	}
+
+	results[2].Optarg = "x"
+	if _, err := results.Int("pin", 0); fmt.Sprint(err) != "invalid integer \"****\": --pin" {
+		t.Errorf("Int(), got %v", err)
+	}
+
+	t.Setenv("TEST_PIN", "1234")
+	results, err = Resolve(options, nil, nil)
+	if err != nil || !equal(results[0].Raw, []string{"TEST_PIN=****"}) {
+		t.Errorf("Resolve(), got %v %v", results, err)
+	}
+	t.Setenv("TEST_PIN", "12x4")
+	_, err = Resolve(options, nil, nil)
+	if got, want := fmt.Sprint(err), "invalid integer \"****\" (from TEST_PIN): --pin"; got != want {
+		t.Errorf("Resolve(), got %q, want %q", got, want)
+	}
+}
+
+func TestUntil(t *testing.T) {
+	options := []Option{
+		{Long: "exec", Short: 'x', Kind: KindUntil},
+		{Long: "batch", Kind: KindUntil, Until: "+"},
+		{Long: "verbose", Short: 'v', Kind: KindNone},
+	}
+	table := []struct {
+		args []string
+		want []string
+		rest []string
+		err  error
+	}{
+		{[]string{"", "--exec", "rm", "{}", ";", "-v", "a"}, []string{"--exec rm {} ;", "--verbose"}, []string{"a"}, nil},
+		{[]string{"", "-vx", ";", "b"}, []string{"--verbose", "--exec ;"}, []string{"b"}, nil},
+		{[]string{"", "-xrm", "-v", ";"}, []string{"--exec rm -v ;"}, nil, nil},
+		{[]string{"", "--exec=rm", "--", ";", "--"}, []string{"--exec rm -- ;"}, nil, nil},
+		{[]string{"", "--batch", "a", ";", "+"}, []string{"--batch a ; +"}, nil, nil},
+		{[]string{"", "--exec", "rm", "{}"}, nil, nil, ErrMissing},
+	}
+	for _, row := range table {
+		results, rest, err := Parse(options, row.args)
+		if !errors.Is(err, row.err) {
+			t.Errorf("Parse(%q), got error %v, want %v", row.args, err, row.err)
+			continue
+		}
+		var got []string
+		for _, result := range results {
+			got = append(got, result.String())
+		}
+		if !equal(got, row.want) || !equal(rest, row.rest) {
+			t.Errorf("Parse(%q), got %q %q, want %q %q", row.args, got, rest, row.want, row.rest)
+		}
+	}
+}
+
+func TestPlus(t *testing.T) {
+	options := []Option{
+		{Long: "xtrace", Short: 'x', Kind: KindNone},
+		{Short: 'e', Kind: KindNone},
+		{Short: 'o', Kind: KindRequired},
+	}
+	table := []struct {
+		args []string
+		want []string
+		rest []string
+	}{
+		{[]string{"", "+x", "-e"}, []string{"+x", "-e"}, nil},
+		{[]string{"", "+xe", "+o", "vi", "a"}, []string{"+x", "+e", "+ovi"}, []string{"a"}},
+		{[]string{"", "-x", "+", "-e"}, []string{"--xtrace"}, []string{"+", "-e"}},
+	}
+	for _, row := range table {
+		results, rest, err := ParseWith(Config{Plus: true}, options, row.args)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, result := range results {
+			got = append(got, result.String())
+		}
+		if !equal(got, row.want) || !equal(rest, row.rest) {
+			t.Errorf("ParseWith(%q), got %q %q, want %q %q", row.args, got, rest, row.want, row.rest)
+		}
+		args := append([]string{""}, Unparse(results, rest)...)
+		if again, _, _ := ParseWith(Config{Plus: true}, options, args); fmt.Sprint(again) != fmt.Sprint(results) {
+			t.Errorf("Unparse(%q), got %q, which parses as %v", row.args, args, again)
+		}
+	}
+
+	// only when enabled
+	_, rest, _ := Parse(options, []string{"", "+x"})
+	if !equal(rest, []string{"+x"}) {
+		t.Errorf("Parse(), got %q, want [+x]", rest)
+	}
+}
@@ -0,0 +1,126 @@
+// This is free and unencumbered software released into the public domain.
+
+package optparse
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+)
+
+// Hooks for testing Config.Prompt and Secret.
+var (
+	stdin         io.Reader = os.Stdin
+	stdinTerminal           = func() bool { return fileWidth(os.Stdin) > 0 }
+	readSecret              = func() (string, error) { return readPassword(os.Stdin) }
+)
+
+// secret refuses a Secret option's argument given on the command line
+// unless allowed, and otherwise prompts for it with echo disabled.
+func (p *Parser) secret(result *Result) error {
+	switch {
+	case !result.Secret:
+		return nil
+	case result.Attached || result.Optarg != "":
+		if !p.SecretArgs {
+			return ErrSecret
+		}
+		return nil
+	case p.peeking || !stdinTerminal():
+		if result.Kind == KindRequired && !p.peeking {
+			return ErrMissing
+		}
+		return nil
+	}
+	label(result.Option)
+	answer, err := readSecret()
+	fmt.Fprintln(stderr) // the newline was not echoed
+	if errors.Is(err, io.EOF) && answer == "" {
+		if result.Kind == KindRequired {
+			return ErrMissing
+		}
+		return nil
+	} else if err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	if answer == "" {
+		answer = result.Default
+	}
+	result.Optarg, result.Attached = answer, true
+	return nil
+}
+
+// label writes the prompt for the option's argument.
+func label(option Option) {
+	fmt.Fprint(stderr, option.names())
+	if option.Help != "" {
+		fmt.Fprintf(stderr, " (%s)", option.Help)
+	}
+	if option.Default != "" && !option.Secret {
+		fmt.Fprintf(stderr, " [%s]", option.Default)
+	}
+	fmt.Fprint(stderr, ": ")
+}
+
+// ask prompts for each Required option absent from results[start:] if
+// enabled, returning results with the answers appended.
+func (p *Parser) ask(options []Option, results Results, start int) Results {
+	if !p.Prompt {
+		return results
+	}
+	var r *bufio.Reader
+	for _, option := range options {
+		if !option.Required || slices.ContainsFunc(results[start:], option.defines) {
+			continue
+		}
+		if r == nil {
+			if !stdinTerminal() {
+				return results
+			}
+			r = bufio.NewReader(stdin)
+		}
+		if result, ok := prompt(r, option); ok {
+			results = append(results, result)
+		}
+	}
+	return results
+}
+
+// prompt asks for the option's argument until given a valid answer,
+// reporting false at the end of input.
+func prompt(r *bufio.Reader, option Option) (Result, bool) {
+	for {
+		label(option)
+		var line string
+		var err error
+		if option.Secret {
+			line, err = readSecret()
+			fmt.Fprintln(stderr)
+		} else {
+			line, err = r.ReadString('\n')
+		}
+		if err != nil && line == "" {
+			fmt.Fprintln(stderr)
+			return Result{}, false
+		}
+		answer := strings.TrimRight(line, "\r\n")
+		if answer == "" {
+			answer = option.Default
+		}
+		if answer != "" {
+			result, ok, err := assign(option, answer, true)
+			switch {
+			case err != nil:
+				fmt.Fprintf(stderr, "%s\n", err)
+			case !ok:
+				fmt.Fprintf(stderr, "%s is required\n", option.names())
+			default:
+				return result, true
+			}
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package optparse
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// fakeTerminal replaces standard input and error for prompting.
+func fakeTerminal(t *testing.T, input string, terminal bool) *bytes.Buffer {
+	oldStdin, oldStderr, oldTerminal := stdin, stderr, stdinTerminal
+	t.Cleanup(func() { stdin, stderr, stdinTerminal = oldStdin, oldStderr, oldTerminal })
+	var buf bytes.Buffer
+	stdin = strings.NewReader(input)
+	stderr = &buf
+	stdinTerminal = func() bool { return terminal }
+	return &buf
+}
+
+func TestPrompt(t *testing.T) {
+	options := []Option{
+		{Long: "name", Short: 'n', Kind: KindRequired, Required: true, Help: "your name"},
+		{Long: "jobs", Kind: KindRequired, Type: TypeInt, Required: true, Default: "4"},
+		{Long: "verbose", Short: 'v', Kind: KindNone},
+	}
+	config := Config{Prompt: true}
+
+	out := fakeTerminal(t, "\nbob\nmany\n\n", true)
+	results, _, err := ParseWith(config, options, []string{"", "-v"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	name, _ := results.Get("name")
+	if name != "bob" || results[2].Value != 4 {
+		t.Errorf("ParseWith(), got %v", results)
+	}
+	want := "--name (-n) (your name): --name (-n) (your name): " +
+		"--jobs [4]: invalid integer \"many\"\n--jobs [4]: "
+	if got := out.String(); got != want {
+		t.Errorf("ParseWith(), got prompts %q, want %q", got, want)
+	}
+
+	// end of input
+	out = fakeTerminal(t, "", true)
+	_, _, err = ParseWith(config, options, []string{"", "--jobs=1"})
+	if !errors.Is(err, ErrRequired) || out.String() != "--name (-n) (your name): \n" {
+		t.Errorf("ParseWith(), got %v %q, want %v", err, out, ErrRequired)
+	}
+
+	// not a terminal
+	out = fakeTerminal(t, "bob\n", false)
+	_, _, err = ParseWith(config, options, []string{""})
+	if !errors.Is(err, ErrRequired) || out.Len() != 0 {
+		t.Errorf("ParseWith(), got %v %q, want %v", err, out, ErrRequired)
+	}
+
+	// only when enabled
+	out = fakeTerminal(t, "bob\n", true)
+	_, _, err = Parse(options, []string{""})
+	if !errors.Is(err, ErrRequired) || out.Len() != 0 {
+		t.Errorf("Parse(), got %v %q, want %v", err, out, ErrRequired)
+	}
+}
+
+func TestPromptNone(t *testing.T) {
+	options := []Option{{Long: "agree", Kind: KindNone, Required: true}}
+	out := fakeTerminal(t, "no\nyes\n", true)
+	results, _, err := ParseWith(Config{Prompt: true}, options, []string{""})
+	if err != nil || !results.Has("agree") {
+		t.Errorf("ParseWith(), got %v %v", results, err)
+	}
+	want := "--agree: --agree is required\n--agree: "
+	if got := out.String(); got != want {
+		t.Errorf("ParseWith(), got prompts %q, want %q", got, want)
+	}
+}
+
+func TestCommandPrompt(t *testing.T) {
+	root := &Command{
+		Name: "app",
+		Commands: []*Command{{
+			Name:    "init",
+			Options: []Option{{Long: "name", Kind: KindRequired, Required: true}},
+			Config:  Config{Prompt: true},
+		}},
+	}
+	fakeTerminal(t, "bob\n", true)
+	inv, err := root.Parse([]string{"app", "init"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name, _ := inv.Results[1].Get("name"); name != "bob" {
+		t.Errorf("Parse(), got %q, want bob", name)
+	}
+}
+
+// fakeSecrets replaces reading secrets with the given answers, then the
+// end of input.
+func fakeSecrets(t *testing.T, answers ...string) {
+	old := readSecret
+	t.Cleanup(func() { readSecret = old })
+	readSecret = func() (string, error) {
+		if len(answers) == 0 {
+			return "", io.EOF
+		}
+		answer := answers[0]
+		answers = answers[1:]
+		return answer, nil
+	}
+}
+
+func TestSecret(t *testing.T) {
+	options := []Option{
+		{Long: "password", Short: 'p', Kind: KindRequired, Secret: true, Help: "login"},
+		{Long: "verbose", Short: 'v', Kind: KindNone},
+	}
+	table := []struct {
+		config   Config
+		args     []string
+		terminal bool
+		answers  []string
+		want     string
+		rest     []string
+		err      error
+	}{
+		{Config{}, []string{"", "--password"}, true, []string{"hunter2"}, "hunter2", nil, nil},
+		{Config{}, []string{"", "-vp", "file"}, true, []string{"hunter2"}, "hunter2", []string{"file"}, nil},
+		{Config{}, []string{"", "-p"}, true, nil, "", nil, ErrMissing},
+		{Config{}, []string{"", "--password"}, false, nil, "", nil, ErrMissing},
+		{Config{}, []string{"", "--password=hunter2"}, true, nil, "", nil, ErrSecret},
+		{Config{}, []string{"", "-phunter2"}, true, nil, "", nil, ErrSecret},
+		{Config{SecretArgs: true}, []string{"", "--password", "hunter2"}, false, nil, "hunter2", nil, nil},
+		{Config{SecretArgs: true}, []string{"", "-phunter2"}, false, nil, "hunter2", nil, nil},
+		{Config{SecretArgs: true}, []string{"", "--password"}, true, []string{"x"}, "", nil, ErrMissing},
+	}
+	for _, row := range table {
+		out := fakeTerminal(t, "", row.terminal)
+		fakeSecrets(t, row.answers...)
+		results, rest, err := ParseWith(row.config, options, row.args)
+		if !errors.Is(err, row.err) {
+			t.Errorf("ParseWith(%q), got error %v, want %v", row.args, err, row.err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		got, _ := results.Get("password")
+		if got != row.want || !slices.Equal(rest, row.rest) {
+			t.Errorf("ParseWith(%q), got %q %q, want %q %q", row.args, got, rest, row.want, row.rest)
+		}
+		if row.answers != nil && out.String() != "--password (-p) (login): \n" {
+			t.Errorf("ParseWith(%q), got prompt %q", row.args, out)
+		}
+	}
+}
+
+func TestSecretPrompt(t *testing.T) {
+	options := []Option{
+		{Long: "token", Kind: KindRequired, Secret: true, Required: true},
+	}
+	out := fakeTerminal(t, "", true)
+	fakeSecrets(t, "s3cret")
+	results, _, err := ParseWith(Config{Prompt: true}, options, []string{""})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := results.Get("token"); got != "s3cret" || out.String() != "--token: \n" {
+		t.Errorf("ParseWith(), got %q with prompts %q", got, out)
+	}
+}
@@ -0,0 +1,135 @@
+// This is free and unencumbered software released into the public domain.
+
+package optparse
+
+import (
+	"fmt"
+	"os"
+)
+
+// Source is an enumeration of where a Result came from, in order of
+// precedence for Resolve.
+type Source int
+
+const (
+	// SourceArgs is the command line.
+	SourceArgs Source = iota
+	// SourceEnv is the option's EnvVar.
+	SourceEnv
+	// SourceConfig is a config file.
+	SourceConfig
+	// SourceDefault is the option's Default.
+	SourceDefault
+)
+
+func (s Source) String() string {
+	switch s {
+	case SourceArgs:
+		return "command line"
+	case SourceEnv:
+		return "environment"
+	case SourceConfig:
+		return "config file"
+	case SourceDefault:
+		return "default"
+	}
+	return fmt.Sprintf("Source(%d)", int(s))
+}
+
+// Resolve combines the values of the options from, in order of
+// precedence, the command-line results, each option's EnvVar, the config
+// file results, such as from ParseConfigFile, and each option's Default.
+// Each option takes every result from the highest-precedence source
+// setting it, and the rest are dropped, so repeated options are not
+// mixed across sources. Source reports the provenance of each result.
+// Command-line and config results keep their order, followed by values
+// from the environment and defaults in the order of options.
+//
+// Environment variables that are unset or empty are ignored. They and
+// defaults are converted and validated like config file values, and
+// options without an argument take booleans. Errors are Errors for the
+// offending option.
+func Resolve(options []Option, args, config Results) (Results, error) {
+	merged := make(Results, 0, len(args)+len(config))
+	merged = append(merged, args...)
+	for _, result := range config {
+		result.Source = max(result.Source, SourceConfig)
+		merged = append(merged, result)
+	}
+	return resolve(options, merged)
+}
+
+// Resolve resolves each command's results, as with the package-level
+// Resolve, after merging any config files with MergeConfigFile and the
+// like.
+func (inv *Invocation) Resolve() error {
+	for i, c := range inv.Commands {
+		results, err := resolve(c.Options, inv.Results[i])
+		inv.Results[i] = results
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resolve keeps the results of the highest-precedence source for each
+// option, consulting EnvVar and Default for options not set by results
+// from a higher-precedence source.
+func resolve(options []Option, results Results) (Results, error) {
+	best := make([]Source, len(options))
+	var extra Results
+	for i, option := range options {
+		best[i] = SourceDefault + 1
+		for _, result := range results {
+			if option.defines(result) {
+				best[i] = min(best[i], result.Source)
+			}
+		}
+
+		var value string
+		var source Source
+		switch {
+		case best[i] > SourceEnv && option.EnvVar != "" && os.Getenv(option.EnvVar) != "":
+			value, source = os.Getenv(option.EnvVar), SourceEnv
+		case best[i] > SourceDefault && option.Default != "":
+			value, source = option.Default, SourceDefault
+		default:
+			continue
+		}
+		best[i] = source
+		result, ok, err := assign(option, value, true)
+		if err != nil {
+			if source == SourceEnv {
+				err = fmt.Errorf("%w (from %s)", err, option.EnvVar)
+			}
+			return results, Error{Option: option, Err: err}
+		}
+		if ok {
+			result.Source = source
+			if option.Sensitive {
+				value = mask
+			}
+			result.Raw = []string{value}
+			if source == SourceEnv {
+				result.Raw = []string{option.EnvVar + "=" + value}
+			}
+			extra = append(extra, result)
+		}
+	}
+
+	var resolved Results
+	for _, result := range results {
+		keep := true
+		for i, option := range options {
+			if option.defines(result) && result.Source != best[i] {
+				keep = false
+				break
+			}
+		}
+		if keep {
+			resolved = append(resolved, result)
+		}
+	}
+	return append(resolved, extra...), nil
+}
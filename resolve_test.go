@@ -0,0 +1,117 @@
+package optparse
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	options := []Option{
+		{Long: "color", Kind: KindRequired, Default: "auto", EnvVar: "TEST_COLOR"},
+		{Long: "jobs", Kind: KindRequired, Type: TypeInt, Default: "1", EnvVar: "TEST_JOBS"},
+		{Long: "include", Short: 'I', Kind: KindRequired, EnvVar: "TEST_INCLUDE"},
+		{Long: "verbose", Short: 'v', Kind: KindNone, EnvVar: "TEST_VERBOSE"},
+		{Long: "quiet", Kind: KindNone, Default: "true"},
+		{Long: "pager", Kind: KindRequired},
+	}
+	t.Setenv("TEST_COLOR", "")
+	t.Setenv("TEST_JOBS", "4")
+	t.Setenv("TEST_INCLUDE", "env")
+	t.Setenv("TEST_VERBOSE", "off")
+
+	args, _, err := Parse(options, []string{"", "-I", "a", "-I", "b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	config, err := ParseINI(strings.NewReader("include = c\ncolor = never\nverbose\npager = less\n"), options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	results, err := Resolve(options, args, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []struct {
+		long, optarg string
+		source       Source
+	}{
+		{"include", "a", SourceArgs},
+		{"include", "b", SourceArgs},
+		{"color", "never", SourceConfig},
+		{"pager", "less", SourceConfig},
+		{"jobs", "4", SourceEnv},
+		{"quiet", "", SourceDefault},
+	}
+	if len(results) != len(want) {
+		t.Fatalf("Resolve(), got %d results %v, want %d", len(results), results, len(want))
+	}
+	for i, w := range want {
+		r := results[i]
+		if r.Long != w.long || r.Optarg != w.optarg || r.Source != w.source {
+			t.Errorf("Resolve()[%d], got %q %q %v, want %q %q %v",
+				i, r.Long, r.Optarg, r.Source, w.long, w.optarg, w.source)
+		}
+	}
+	if jobs, _ := results.Int("jobs", 0); jobs != 4 || results[4].Value != 4 {
+		t.Errorf("Resolve(), got jobs %d %v, want 4", jobs, results[4].Value)
+	}
+	if got := results[4].Raw; !equal(got, []string{"TEST_JOBS=4"}) {
+		t.Errorf("Resolve(), got Raw %q", got)
+	}
+
+	t.Setenv("TEST_JOBS", "x")
+	_, err = Resolve(options, nil, nil)
+	if want := `invalid integer "x" (from TEST_JOBS): --jobs`; err == nil || err.Error() != want {
+		t.Errorf("Resolve(), got %v, want %q", err, want)
+	}
+}
+
+func TestInvocationResolve(t *testing.T) {
+	root := &Command{
+		Name: "app",
+		Options: []Option{
+			{Long: "color", Kind: KindRequired, Default: "auto", EnvVar: "TEST_COLOR", Persistent: true},
+		},
+		Commands: []*Command{{
+			Name:    "run",
+			Options: []Option{{Long: "jobs", Kind: KindRequired, Default: "1", EnvVar: "TEST_JOBS"}},
+		}},
+	}
+	t.Setenv("TEST_COLOR", "never")
+	t.Setenv("TEST_JOBS", "")
+
+	inv, err := root.Parse([]string{"app", "run"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := inv.MergeJSON(strings.NewReader(`{"color": "always", "run": {"jobs": 2}}`)); err != nil {
+		t.Fatal(err)
+	}
+	if err := inv.Resolve(); err != nil {
+		t.Fatal(err)
+	}
+	color, _ := inv.Results[0].Get("color")
+	jobs, _ := inv.Results[1].Get("jobs")
+	if color != "never" || jobs != "2" || len(inv.Results[0]) != 1 || len(inv.Results[1]) != 1 {
+		t.Errorf("Resolve(), got %v", inv.Results)
+	}
+	if inv.Results[0][0].Source != SourceEnv || inv.Results[1][0].Source != SourceConfig {
+		t.Errorf("Resolve(), got sources %v %v", inv.Results[0][0].Source, inv.Results[1][0].Source)
+	}
+
+	// results for options outside the table are kept
+	inv.Results[1] = append(inv.Results[1], Result{Option: Option{Long: "bogus"}})
+	if err := inv.Resolve(); err != nil || len(inv.Results[1]) != 2 {
+		t.Errorf("Resolve(), got %v %v", err, inv.Results[1])
+	}
+}
+
+func TestSourceString(t *testing.T) {
+	if got := SourceEnv.String(); got != "environment" {
+		t.Errorf("String(), got %q", got)
+	}
+	if got := Source(9).String(); got != "Source(9)" {
+		t.Errorf("String(), got %q", got)
+	}
+}
@@ -0,0 +1,145 @@
+// This is free and unencumbered software released into the public domain.
+
+package optparse
+
+import (
+	"time"
+	"unicode/utf8"
+)
+
+// Results is a slice of parse results with convenience lookups. Each
+// lookup accepts either a long name ("color") or a short option as a
+// one-character string ("c").
+type Results []Result
+
+// name returns the canonical name of an option: its long name if it has
+// one, otherwise its short option.
+func (o Option) name() string {
+	if o.Long != "" {
+		return o.Long
+	}
+	return string(o.Short)
+}
+
+// is reports whether the option is identified by the given name.
+func (o Option) is(name string) bool {
+	if o.Long != "" && o.Long == name {
+		return true
+	}
+	short, size := utf8.DecodeRuneInString(name)
+	return o.Short != 0 && o.Short == short && size == len(name)
+}
+
+// Has reports whether the named option was given.
+func (r Results) Has(name string) bool {
+	return r.Count(name) > 0
+}
+
+// Get returns the argument of the last occurrence of the named option,
+// following the convention that later options override earlier ones.
+// The boolean reports whether the option was given at all.
+func (r Results) Get(name string) (string, bool) {
+	for i := len(r) - 1; i >= 0; i-- {
+		if r[i].is(name) {
+			return r[i].Optarg, true
+		}
+	}
+	return "", false
+}
+
+// GetAll returns the arguments of every occurrence of the named option,
+// in order, for options that may be repeated.
+func (r Results) GetAll(name string) []string {
+	var optargs []string
+	for _, result := range r {
+		if result.is(name) {
+			optargs = append(optargs, result.Optarg)
+		}
+	}
+	return optargs
+}
+
+// Count returns the number of times the named option was given, such
+// as for a repeated -v to increase verbosity.
+func (r Results) Count(name string) int {
+	count := 0
+	for _, result := range r {
+		if result.is(name) {
+			count++
+		}
+	}
+	return count
+}
+
+// Map converts the results into a map from canonical option name, the
+// long name if present and otherwise the short option, to the argument
+// of each occurrence in order. Options without arguments map to empty
+// strings, one per occurrence.
+func (r Results) Map() map[string][]string {
+	m := make(map[string][]string)
+	for _, result := range r {
+		name := result.name()
+		m[name] = append(m[name], result.Optarg)
+	}
+	return m
+}
+
+// value finds the last occurrence of the named option and converts its
+// argument, wrapping any conversion error in an Error for that option.
+func value[T any](r Results, name string, def T, convert func(string) (T, error)) (T, error) {
+	for i := len(r) - 1; i >= 0; i-- {
+		result := r[i]
+		if !result.is(name) {
+			continue
+		}
+		v, err := convert(result.Optarg)
+		if err != nil {
+			var arg string
+			if len(result.Raw) > 0 {
+				arg = result.Raw[0]
+			}
+			return def, Error{
+				Option: result.Option,
+				Err:    masked(result.Option, err),
+				Index:  result.Index,
+				Arg:    arg,
+			}
+		}
+		return v, nil
+	}
+	return def, nil
+}
+
+// Int returns the argument of the last occurrence of the named option
+// as an integer, or def if the option was not given. The argument may
+// use a base prefix as with the flag package.
+func (r Results) Int(name string, def int) (int, error) {
+	return value(r, name, def, convertInt)
+}
+
+// Uint is like Int but for unsigned integers.
+func (r Results) Uint(name string, def uint) (uint, error) {
+	return value(r, name, def, convertUint)
+}
+
+// Float is like Int but for floating point numbers.
+func (r Results) Float(name string, def float64) (float64, error) {
+	return value(r, name, def, convertFloat)
+}
+
+// Duration is like Int but for durations as accepted by
+// time.ParseDuration.
+func (r Results) Duration(name string, def time.Duration) (time.Duration, error) {
+	return value(r, name, def, convertDuration)
+}
+
+// Bool is like Int but for booleans as accepted by strconv.ParseBool,
+// except that an option given without an argument is true.
+func (r Results) Bool(name string, def bool) (bool, error) {
+	return value(r, name, def, func(s string) (bool, error) {
+		if s == "" {
+			return true, nil
+		}
+		return convertBool(s)
+	})
+}
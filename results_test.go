@@ -0,0 +1,112 @@
+package optparse
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestResults(t *testing.T) {
+	args := []string{"", "-eee", "--delay=1", "-cred", "-d", "2", "--erase", "-π"}
+	results, _, err := Parse(options, args)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !results.Has("color") || !results.Has("c") || results.Has("amend") {
+		t.Errorf("Has(), wrong results for %q", args[1:])
+	}
+	if results.Has("") || results.Has("co") || results.Has("cc") {
+		t.Errorf("Has(), matched a bad name")
+	}
+	if !results.Has("π") || !results.Has("pi") {
+		t.Errorf("Has(), failed to match multibyte short option")
+	}
+
+	if got, ok := results.Get("d"); !ok || got != "2" {
+		t.Errorf(`Get("d"), got %q %v, want "2" true`, got, ok)
+	}
+	if got, ok := results.Get("brief"); ok || got != "" {
+		t.Errorf(`Get("brief"), got %q %v, want "" false`, got, ok)
+	}
+
+	if got, want := results.GetAll("delay"), []string{"1", "2"}; !equal(got, want) {
+		t.Errorf(`GetAll("delay"), got %q, want %q`, got, want)
+	}
+	if got := results.GetAll("amend"); got != nil {
+		t.Errorf(`GetAll("amend"), got %q, want nil`, got)
+	}
+
+	if got := results.Count("e"); got != 4 {
+		t.Errorf(`Count("e"), got %d, want 4`, got)
+	}
+}
+
+func TestResultsMap(t *testing.T) {
+	args := []string{"", "-as", "--delay=1", "-c", "-d", "2", "-ss"}
+	results, _, err := Parse(options, args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string][]string{
+		"amend": {""},
+		"s":     {"", "", ""},
+		"delay": {"1", "2"},
+		"color": {""},
+	}
+	if got := results.Map(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Map(), got %q, want %q", got, want)
+	}
+}
+
+func TestResultsTyped(t *testing.T) {
+	options := []Option{
+		{Long: "jobs", Short: 'j', Kind: KindRequired},
+		{Long: "ratio", Short: 'r', Kind: KindRequired},
+		{Long: "timeout", Short: 't', Kind: KindRequired},
+		{Long: "verbose", Short: 'v', Kind: KindOptional},
+		{Long: "size", Short: 's', Kind: KindRequired},
+	}
+	args := []string{"", "-j", "0x10", "-r2.5", "--timeout=1m30s", "-v", "-s", "-1"}
+	results, _, err := Parse(options, args)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if v, err := results.Int("jobs", 1); v != 16 || err != nil {
+		t.Errorf(`Int("jobs"), got %v %v, want 16`, v, err)
+	}
+	if v, err := results.Int("missing", 7); v != 7 || err != nil {
+		t.Errorf(`Int("missing"), got %v %v, want 7`, v, err)
+	}
+	if v, err := results.Float("r", 0); v != 2.5 || err != nil {
+		t.Errorf(`Float("r"), got %v %v, want 2.5`, v, err)
+	}
+	if v, err := results.Duration("timeout", time.Second); v != 90*time.Second || err != nil {
+		t.Errorf(`Duration("timeout"), got %v %v, want 1m30s`, v, err)
+	}
+	if v, err := results.Bool("verbose", false); !v || err != nil {
+		t.Errorf(`Bool("verbose"), got %v %v, want true`, v, err)
+	}
+
+	v, err := results.Uint("size", 3)
+	var e Error
+	if v != 3 || !errors.As(err, &e) {
+		t.Fatalf(`Uint("size"), got %v %v, want error`, v, err)
+	}
+	want := `invalid unsigned integer "-1": --size (-s)`
+	if e.Long != "size" || e.Index != 6 || e.Arg != "-s" || e.Error() != want {
+		t.Errorf(`Uint("size"), got %#v, want %q`, e, want)
+	}
+
+	results, _, _ = Parse(options, []string{"", "--ratio=1e999", "-vmaybe"})
+	if _, err := results.Float("ratio", 0); err == nil ||
+		err.Error() != `number out of range "1e999": --ratio (-r)` {
+		t.Errorf(`Float("ratio"), got %v`, err)
+	}
+	if _, err := results.Bool("v", false); err == nil ||
+		err.Error() != `invalid boolean "maybe": --verbose (-v)` {
+		t.Errorf(`Bool("v"), got %v`, err)
+	}
+}
@@ -0,0 +1,104 @@
+// This is free and unencumbered software released into the public domain.
+
+package optparse
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// SampleINI writes a template INI config file, for ParseINI, listing
+// each option by its long name as a commented-out setting of its
+// Default, preceded by its Help and any Choices:
+//
+//	; colorize the output
+//	; one of: auto, always, never
+//	;color = auto
+//
+// Options with dotted names are placed in sections, so --net.delay
+// appears as delay under [net]. Options without a long name, Prefix
+// options, and Load options are omitted.
+func SampleINI(w io.Writer, options []Option) error {
+	return sample(w, options, ";", func(option Option) string {
+		if option.Kind == KindNone {
+			return strconv.FormatBool(option.Default != "")
+		}
+		return option.Default
+	})
+}
+
+// SampleTOML is like SampleINI but writes TOML, for ParseTOML. Values
+// of numeric and boolean types are unquoted.
+func SampleTOML(w io.Writer, options []Option) error {
+	return sample(w, options, "#", func(option Option) string {
+		switch {
+		case option.Kind == KindNone:
+			return strconv.FormatBool(option.Default != "")
+		case option.Type == TypeString || option.Type == TypeDuration:
+			return strconv.Quote(option.Default)
+		case option.Default != "":
+			return option.Default
+		case option.Type == TypeBool:
+			return "false"
+		}
+		return "0"
+	})
+}
+
+// sample writes a template config file with the given comment marker
+// and formatting of each option's value.
+func sample(w io.Writer, options []Option, marker string, value func(Option) string) error {
+	sections := []string{""}
+	for _, option := range options {
+		section, key := sampleName(option)
+		if key != "" && !slices.Contains(sections, section) {
+			sections = append(sections, section)
+		}
+	}
+
+	b := bufio.NewWriter(w)
+	first := true
+	for _, section := range sections {
+		if section != "" {
+			fmt.Fprintf(b, "\n[%s]\n", section)
+			first = true
+		}
+		for _, option := range options {
+			s, key := sampleName(option)
+			if key == "" || s != section {
+				continue
+			}
+			if !first {
+				fmt.Fprintln(b)
+			}
+			first = false
+			for _, line := range wrap(option.Help, 72) {
+				if line != "" {
+					fmt.Fprintf(b, "%s %s\n", marker, line)
+				}
+			}
+			if len(option.Choices) > 0 {
+				fmt.Fprintf(b, "%s one of: %s\n", marker, strings.Join(option.Choices, ", "))
+			}
+			line := fmt.Sprintf("%s%s = %s", marker, key, value(option))
+			fmt.Fprintln(b, strings.TrimRight(line, " "))
+		}
+	}
+	return b.Flush()
+}
+
+// sampleName returns the section and key of the option in a sample
+// config file, with an empty key for options omitted.
+func sampleName(option Option) (section, key string) {
+	if option.Long == "" || option.Prefix || option.Load {
+		return "", ""
+	}
+	if i := strings.LastIndexByte(option.Long, '.'); i >= 0 {
+		return option.Long[:i], option.Long[i+1:]
+	}
+	return "", option.Long
+}
@@ -0,0 +1,97 @@
+package optparse
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+var sampleOptions = []Option{
+	{Long: "config", Kind: KindRequired, Load: true},
+	{Long: "color", Kind: KindRequired, Help: "colorize the output",
+		Choices: []string{"auto", "never"}, Default: "auto"},
+	{Long: "net.delay", Kind: KindRequired, Type: TypeDuration, Help: "wait between requests"},
+	{Long: "net.retries", Kind: KindRequired, Type: TypeInt, Default: "3"},
+	{Long: "verbose", Short: 'v', Kind: KindNone, Help: "print more"},
+	{Long: "x-", Kind: KindRequired, Prefix: true},
+	{Short: 'q', Kind: KindNone},
+	{Long: "jobs", Kind: KindRequired, Type: TypeInt},
+	{Long: "name", Kind: KindRequired},
+}
+
+func TestSampleINI(t *testing.T) {
+	var buf bytes.Buffer
+	SampleINI(&buf, sampleOptions)
+	want := `; colorize the output
+; one of: auto, never
+;color = auto
+
+; print more
+;verbose = false
+
+;jobs =
+
+;name =
+
+[net]
+; wait between requests
+;delay =
+
+;retries = 3
+`
+	if got := buf.String(); got != want {
+		t.Errorf("SampleINI(), got:\n%s\nwant:\n%s", got, want)
+	}
+
+	// uncommented, the template parses
+	text := strings.ReplaceAll(want, ";jobs =", ";jobs = 1")
+	text = strings.ReplaceAll(text, ";delay =", ";delay = 1s")
+	if _, err := ParseINI(strings.NewReader(uncomment(text, ";")), sampleOptions); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSampleTOML(t *testing.T) {
+	var buf bytes.Buffer
+	SampleTOML(&buf, sampleOptions)
+	want := `# colorize the output
+# one of: auto, never
+#color = "auto"
+
+# print more
+#verbose = false
+
+#jobs = 0
+
+#name = ""
+
+[net]
+# wait between requests
+#delay = ""
+
+#retries = 3
+`
+	if got := buf.String(); got != want {
+		t.Errorf("SampleTOML(), got:\n%s\nwant:\n%s", got, want)
+	}
+
+	text := strings.ReplaceAll(want, `#delay = ""`, `#delay = "1s"`)
+	results, err := ParseTOML(strings.NewReader(uncomment(text, "#")), sampleOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if retries, _ := results.Get("net.retries"); retries != "3" {
+		t.Errorf("ParseTOML(), got retries %q, want 3", retries)
+	}
+}
+
+// uncomment enables the settings of a sample config file.
+func uncomment(text, marker string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, marker) && !strings.HasPrefix(line, marker+" ") {
+			lines[i] = line[len(marker):]
+		}
+	}
+	return strings.Join(lines, "\n")
+}
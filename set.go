@@ -0,0 +1,127 @@
+// This is free and unencumbered software released into the public domain.
+
+package optparse
+
+import "strconv"
+
+// Set builds an option table along with bindings from each option to a
+// variable, for those who prefer method chaining to a table of struct
+// literals. Methods that refine an option, such as Help and Required,
+// apply to the most recently added option.
+//
+//	var verbose bool
+//	var color string
+//	var jobs int
+//	rest, err := optparse.NewSet().
+//		Bool(&verbose, "verbose", 'v').Help("print more").
+//		StringDefault(&color, "color", 'c', "auto").
+//		Int(&jobs, "jobs", 'j').Required().
+//		Parse(os.Args)
+type Set struct {
+	options  []Option
+	defaults []func()
+}
+
+// NewSet returns an empty Set.
+func NewSet() *Set {
+	return new(Set)
+}
+
+func (s *Set) add(option Option, def func()) *Set {
+	s.options = append(s.options, option)
+	s.defaults = append(s.defaults, def)
+	return s
+}
+
+// last returns the most recently added option.
+func (s *Set) last() *Option {
+	if len(s.options) == 0 {
+		panic("optparse: no option to modify")
+	}
+	return &s.options[len(s.options)-1]
+}
+
+// Bool adds an option without an argument that sets *p to true.
+func (s *Set) Bool(p *bool, long string, short rune) *Set {
+	return s.add(Option{
+		Long:  long,
+		Short: short,
+		Kind:  KindNone,
+		Func:  func(string) error { *p = true; return nil },
+	}, nil)
+}
+
+// String adds an option whose required argument is stored in *p.
+func (s *Set) String(p *string, long string, short rune) *Set {
+	return s.add(Option{
+		Long:  long,
+		Short: short,
+		Kind:  KindRequired,
+		Func:  func(optarg string) error { *p = optarg; return nil },
+	}, nil)
+}
+
+// StringDefault is like String, but *p is set to def before parsing.
+func (s *Set) StringDefault(p *string, long string, short rune, def string) *Set {
+	s.String(p, long, short)
+	s.defaults[len(s.defaults)-1] = func() { *p = def }
+	s.last().Default = def
+	return s
+}
+
+// Int adds an option whose required integer argument is stored in *p.
+func (s *Set) Int(p *int, long string, short rune) *Set {
+	return s.add(Option{
+		Long:  long,
+		Short: short,
+		Kind:  KindRequired,
+		Type:  TypeInt,
+		Func: func(optarg string) (err error) {
+			*p, err = convertInt(optarg)
+			return
+		},
+	}, nil)
+}
+
+// IntDefault is like Int, but *p is set to def before parsing.
+func (s *Set) IntDefault(p *int, long string, short rune, def int) *Set {
+	s.Int(p, long, short)
+	s.defaults[len(s.defaults)-1] = func() { *p = def }
+	s.last().Default = strconv.Itoa(def)
+	return s
+}
+
+// Required marks the most recent option as required.
+func (s *Set) Required() *Set {
+	s.last().Required = true
+	return s
+}
+
+// Env documents an environment variable for the most recent option.
+func (s *Set) Env(name string) *Set {
+	s.last().EnvVar = name
+	return s
+}
+
+// Help sets the help text of the most recent option.
+func (s *Set) Help(text string) *Set {
+	s.last().Help = text
+	return s
+}
+
+// Options returns the option table built so far.
+func (s *Set) Options() []Option {
+	return s.options
+}
+
+// Parse sets the defaults, then parses args with Run, storing each
+// option's argument in its bound variable. It returns the remaining
+// arguments.
+func (s *Set) Parse(args []string) ([]string, error) {
+	for _, def := range s.defaults {
+		if def != nil {
+			def()
+		}
+	}
+	return Run(s.options, args)
+}
@@ -0,0 +1,45 @@
+package optparse
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSet(t *testing.T) {
+	var verbose bool
+	var color string
+	var jobs int
+	var name string
+	set := NewSet().
+		Bool(&verbose, "verbose", 'v').Help("print more").
+		StringDefault(&color, "color", 'c', "auto").Env("APP_COLOR").
+		IntDefault(&jobs, "jobs", 'j', 1).
+		String(&name, "name", 'n').Required()
+
+	rest, err := set.Parse([]string{"", "-vj4", "--name", "x", "y"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !verbose || color != "auto" || jobs != 4 || name != "x" || !equal(rest, []string{"y"}) {
+		t.Errorf("Parse(), got %v %q %d %q %q", verbose, color, jobs, name, rest)
+	}
+
+	options := set.Options()
+	if len(options) != 4 || options[0].Help != "print more" || !options[3].Required ||
+		options[1].Default != "auto" || options[1].EnvVar != "APP_COLOR" || options[2].Default != "1" {
+		t.Errorf("Options(), got %v", options)
+	}
+
+	_, err = set.Parse([]string{"", "-c", "never"})
+	if !errors.Is(err, ErrRequired) || color != "never" {
+		t.Errorf("Parse(), got %v %q, want %v", err, color, ErrRequired)
+	}
+	if err.Error() != "missing required option: --name (-n)" {
+		t.Errorf("Parse(), got %q", err)
+	}
+
+	_, err = set.Parse([]string{"", "-n", "x", "-j", "many"})
+	if err == nil || err.Error() != `invalid integer "many": --jobs (-j)` {
+		t.Errorf("Parse(), got %v", err)
+	}
+}
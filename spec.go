@@ -0,0 +1,151 @@
+// This is free and unencumbered software released into the public domain.
+
+package optparse
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Spec is a validated option table with prebuilt lookup structures, for
+// programs that parse many command lines with the same options. It is
+// safe for concurrent use as long as Config is not modified.
+type Spec struct {
+	// Config adjusts parsing as with ParseWith.
+	Config Config
+
+	options []Option
+	long    map[string]*Option
+	short   map[rune]*Option
+}
+
+// Compile validates an option table as with Validate and prepares it
+// for repeated parsing. The options are
+// copied, so later changes to the slice do not affect the Spec.
+func Compile(options []Option) (*Spec, error) {
+	if err := Validate(options); err != nil {
+		return nil, err
+	}
+	s := &Spec{options: append([]Option(nil), options...)}
+	s.long, s.short = index(s.options)
+	return s, nil
+}
+
+// index builds lookup tables for an option table. Like the linear
+// search, the first of any duplicates wins.
+func index(options []Option) (map[string]*Option, map[rune]*Option) {
+	long := make(map[string]*Option, len(options))
+	short := make(map[rune]*Option, len(options))
+	fill(long, short, options)
+	return long, short
+}
+
+// fill populates empty lookup tables for an option table.
+func fill(long map[string]*Option, short map[rune]*Option, options []Option) {
+	for i := range options {
+		option := &options[i]
+		if _, ok := long[option.Long]; option.Long != "" && !option.Prefix && !ok {
+			long[option.Long] = option
+		}
+		if _, ok := short[option.Short]; option.Short != 0 && !ok {
+			short[option.Short] = option
+		}
+	}
+}
+
+// Parse is like the package-level Parse function but with the compiled
+// options and the Spec's Config.
+func (s *Spec) Parse(args []string) (Results, []string, error) {
+	return s.AppendParse(nil, args)
+}
+
+// AppendParse is like Parse but appends the results to dst, returning
+// the extended slice. When dst has sufficient capacity, parsing a valid
+// command line does not allocate.
+func (s *Spec) AppendParse(dst Results, args []string) (Results, []string, error) {
+	p := Parser{
+		Config:  s.Config,
+		options: s.options,
+		args:    args,
+		longs:   s.long,
+		shorts:  s.short,
+	}
+	return p.parse(dst)
+}
+
+// Validate checks an option table for mistakes that would otherwise go
+// unnoticed or surface as a panic during parsing: duplicate long names,
+// duplicate short options, options with neither a long nor short name,
+// long names containing '=' or beginning with '-', and invalid kinds or
+// types.
+// Every problem found is reported, joined with errors.Join.
+func Validate(options []Option) error {
+	var errs []error
+	long := make(map[string]bool)
+	short := make(map[rune]bool)
+	for i, option := range options {
+		switch {
+		case option.Long == "" && option.Short == 0:
+			errs = append(errs, fmt.Errorf("option %d has no name", i))
+		case strings.ContainsRune(option.Long, '='):
+			errs = append(errs, fmt.Errorf("option --%s contains '='", option.Long))
+		case strings.HasPrefix(option.Long, "-"):
+			errs = append(errs, fmt.Errorf("option --%s begins with '-'", option.Long))
+		case option.Prefix && option.Long == "":
+			errs = append(errs, fmt.Errorf("option %d is a prefix without a long name", i))
+		case long[option.Long]:
+			errs = append(errs, fmt.Errorf("duplicate option --%s", option.Long))
+		}
+		if option.Long != "" {
+			long[option.Long] = true
+		}
+		if option.Short != 0 {
+			if short[option.Short] {
+				errs = append(errs, fmt.Errorf("duplicate option -%c", option.Short))
+			}
+			short[option.Short] = true
+		}
+		if option.Kind < KindNone || option.Kind > KindUntil {
+			errs = append(errs, fmt.Errorf("option %d has invalid kind %d",
+				i, option.Kind))
+		}
+		if option.Type < TypeString || option.Type > TypeDuration {
+			errs = append(errs, fmt.Errorf("option %d has invalid type %d",
+				i, option.Type))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// MergeOptions concatenates option tables, such as global options and a
+// subcommand's options, reporting every long or short name defined in
+// more than one table. Conflicts within a single table are left to
+// Validate.
+func MergeOptions(tables ...[]Option) ([]Option, error) {
+	var merged []Option
+	var errs []error
+	long := make(map[string]int)
+	short := make(map[rune]int)
+	for i, table := range tables {
+		for _, option := range table {
+			if j, ok := long[option.Long]; ok && j != i {
+				errs = append(errs, fmt.Errorf("option --%s in tables %d and %d",
+					option.Long, j, i))
+			} else if option.Long != "" && !ok {
+				long[option.Long] = i
+			}
+			if j, ok := short[option.Short]; ok && j != i {
+				errs = append(errs, fmt.Errorf("option -%c in tables %d and %d",
+					option.Short, j, i))
+			} else if option.Short != 0 && !ok {
+				short[option.Short] = i
+			}
+		}
+		merged = append(merged, table...)
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return merged, nil
+}
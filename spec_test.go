@@ -0,0 +1,185 @@
+package optparse
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestCompile(t *testing.T) {
+	spec, err := Compile(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, args := range [][]string{
+		{"", "-abcblue", "-d10", "foobar"},
+		{"", "--color=red", "-πe", "--long", "-s", "--", "x"},
+		{"", "--foo", "bar"},
+		{"", "-ax"},
+	} {
+		gotResults, gotRest, gotErr := spec.Parse(args)
+		wantResults, wantRest, wantErr := Parse(options, args)
+		if !reflect.DeepEqual(gotResults, wantResults) ||
+			!equal(gotRest, wantRest) ||
+			!reflect.DeepEqual(gotErr, wantErr) {
+			t.Errorf("Spec.Parse(%q), got %v %q %v, want %v %q %v", args[1:],
+				gotResults, gotRest, gotErr, wantResults, wantRest, wantErr)
+		}
+	}
+}
+
+func TestCompileInvalid(t *testing.T) {
+	table := [][]Option{
+		{{Long: "amend", Short: 'a', Kind: KindNone}, {Long: "amend", Short: 'b', Kind: KindNone}},
+		{{Long: "amend", Short: 'a', Kind: KindNone}, {Long: "brief", Short: 'a', Kind: KindNone}},
+		{{Long: "amend", Short: 'a', Kind: Kind(4)}},
+	}
+
+	for _, options := range table {
+		if _, err := Compile(options); err == nil {
+			t.Errorf("Compile(%v), got nil, want error", options)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := Validate(options); err != nil {
+		t.Errorf("Validate(options), got %v", err)
+	}
+
+	table := []struct {
+		options []Option
+		want    string
+	}{
+		{
+			[]Option{{Long: "amend", Short: 'a', Kind: KindNone}, {Long: "amend", Short: 'b', Kind: KindNone}},
+			"duplicate option --amend",
+		},
+		{
+			[]Option{{Long: "amend", Short: 'a', Kind: KindNone}, {Long: "brief", Short: 'a', Kind: KindNone}},
+			"duplicate option -a",
+		},
+		{
+			[]Option{{Long: "amend", Short: 'a', Kind: KindNone}, {Kind: KindNone}},
+			"option 1 has no name",
+		},
+		{
+			[]Option{{Long: "color=auto", Short: 'c', Kind: KindNone}},
+			"option --color=auto contains '='",
+		},
+		{
+			[]Option{{Long: "-color", Short: 'c', Kind: KindNone}},
+			"option ---color begins with '-'",
+		},
+		{
+			[]Option{{Short: 'x', Kind: KindNone, Prefix: true}},
+			"option 0 is a prefix without a long name",
+		},
+		{
+			[]Option{{Long: "amend", Short: 'a', Kind: Kind(-1)}},
+			"option 0 has invalid kind -1",
+		},
+		{
+			[]Option{{Long: "jobs", Short: 'j', Kind: KindRequired, Type: Type(9)}},
+			"option 0 has invalid type 9",
+		},
+		{
+			[]Option{{Long: "amend", Short: 'a', Kind: Kind(4)}, {Long: "amend", Short: 'a', Kind: KindNone}},
+			"option 0 has invalid kind 4\nduplicate option --amend\nduplicate option -a",
+		},
+	}
+
+	for _, row := range table {
+		err := Validate(row.options)
+		if err == nil || err.Error() != row.want {
+			t.Errorf("Validate(%v), got %v, want %q", row.options, err, row.want)
+		}
+	}
+}
+
+func largeTable() ([]Option, []string) {
+	var options []Option
+	args := []string{""}
+	for i := 0; i < 500; i++ {
+		long := fmt.Sprintf("option-%d", i)
+		options = append(options, Option{Long: long, Short: rune(0x4e00 + i), Kind: KindNone})
+		if i%25 == 0 {
+			args = append(args, "--"+long, string([]rune{'-', rune(0x4e00 + i)}))
+		}
+	}
+	return options, args
+}
+
+func TestLargeTable(t *testing.T) {
+	options, args := largeTable()
+	options = append(options, Option{Long: "option-0", Short: 'x', Kind: KindRequired})
+	results, _, err := Parse(options, args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != len(args)-1 {
+		t.Fatalf("got %d results, want %d", len(results), len(args)-1)
+	}
+	if results[0].Short != 0x4e00 {
+		t.Errorf("got %v, want first duplicate", results[0].Option)
+	}
+}
+
+func BenchmarkLargeTable(b *testing.B) {
+	options, args := largeTable()
+	for i := 0; i < b.N; i++ {
+		Parse(options, args)
+	}
+}
+
+func BenchmarkLargeTableSpec(b *testing.B) {
+	options, args := largeTable()
+	spec, err := Compile(options)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		spec.Parse(args)
+	}
+}
+
+func TestAppendParseAllocs(t *testing.T) {
+	spec, err := Compile(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	args := []string{"", "-abcblue", "--delay=10", "-d", "5", "-πe", "--long", "x"}
+	buf := make([]Result, 0, 16)
+	allocs := testing.AllocsPerRun(100, func() {
+		results, _, err := spec.AppendParse(buf[:0], args)
+		if err != nil || len(results) != 8 {
+			t.Fatal(results, err)
+		}
+	})
+	if allocs != 0 {
+		t.Errorf("AppendParse(), got %v allocations, want 0", allocs)
+	}
+}
+
+func TestMergeOptions(t *testing.T) {
+	global := []Option{
+		{Long: "verbose", Short: 'v', Kind: KindNone},
+		{Short: 'q', Kind: KindNone},
+	}
+	merged, err := MergeOptions(global, options)
+	if err != nil || len(merged) != len(global)+len(options) {
+		t.Errorf("MergeOptions(), got %d %v", len(merged), err)
+	}
+
+	conflict := []Option{
+		{Long: "verbose", Kind: KindNone},
+		{Long: "quiet", Short: 'q', Kind: KindNone},
+	}
+	_, err = MergeOptions(global, options, conflict)
+	want := "option --verbose in tables 0 and 2\noption -q in tables 0 and 2"
+	if err == nil || err.Error() != want {
+		t.Errorf("MergeOptions(), got %v, want %q", err, want)
+	}
+}
@@ -0,0 +1,212 @@
+// This is free and unencumbered software released into the public domain.
+
+package optparse
+
+import (
+	"errors"
+	"strings"
+)
+
+var (
+	errQuote     = errors.New("unterminated quote")
+	errBackslash = errors.New("trailing backslash")
+)
+
+// SplitLine splits a string into arguments following POSIX shell
+// quoting rules: words are separated by unquoted whitespace, single
+// quotes preserve everything up to the closing quote, double quotes
+// allow backslash to escape only $, `, ", \ and newline, and an
+// unquoted backslash escapes any character. A backslash-newline pair
+// is removed entirely. No expansions of any kind are performed. The
+// result is suitable for Parse after prepending a program name.
+func SplitLine(s string) ([]string, error) {
+	var args []string
+	var word strings.Builder
+	inword := false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch c {
+
+		case ' ', '\t', '\n':
+			if inword {
+				args = append(args, word.String())
+				word.Reset()
+				inword = false
+			}
+
+		case '\\':
+			i++
+			if i == len(s) {
+				return nil, errBackslash
+			}
+			if s[i] != '\n' {
+				word.WriteByte(s[i])
+				inword = true
+			}
+
+		case '\'':
+			end := strings.IndexByte(s[i+1:], '\'')
+			if end == -1 {
+				return nil, errQuote
+			}
+			word.WriteString(s[i+1 : i+1+end])
+			i += end + 1
+			inword = true
+
+		case '"':
+			inword = true
+			for i++; ; i++ {
+				if i == len(s) {
+					return nil, errQuote
+				}
+				c := s[i]
+				if c == '"' {
+					break
+				}
+				if c == '\\' && i+1 < len(s) {
+					switch s[i+1] {
+					case '$', '`', '"', '\\':
+						i++
+						c = s[i]
+					case '\n':
+						i++
+						continue
+					}
+				}
+				word.WriteByte(c)
+			}
+
+		default:
+			word.WriteByte(c)
+			inword = true
+		}
+	}
+	if inword {
+		args = append(args, word.String())
+	}
+	return args, nil
+}
+
+// Quote renders arguments as a POSIX shell command line, the inverse of
+// SplitLine, such as for logging the result of Unparse. Arguments made
+// only of characters without special meaning are left bare, and the
+// rest are single-quoted, with each embedded single quote closing the
+// quotes and escaped with a backslash.
+func Quote(args []string) string {
+	var line strings.Builder
+	for i, arg := range args {
+		if i > 0 {
+			line.WriteByte(' ')
+		}
+		if bare(arg, i == 0) {
+			line.WriteString(arg)
+			continue
+		}
+		line.WriteByte('\'')
+		line.WriteString(strings.ReplaceAll(arg, "'", `'\''`))
+		line.WriteByte('\'')
+	}
+	return line.String()
+}
+
+// bare reports whether an argument needs no quoting. An = in the first
+// word would make it a variable assignment.
+func bare(arg string, first bool) bool {
+	if arg == "" {
+		return false
+	}
+	for _, c := range []byte(arg) {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case strings.IndexByte("@%+:,./_-", c) >= 0:
+		case c == '=' && !first:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// SplitWindows splits a raw Windows command line into arguments using
+// the same rules as CommandLineToArgvW and the Microsoft C runtime. The
+// first argument, the program name, is delimited only by whitespace or
+// a pair of quotes, with no backslash escapes. In the remaining
+// arguments, 2n backslashes followed by a quote produce n backslashes
+// and a quoting toggle, 2n+1 backslashes followed by a quote produce n
+// backslashes and a literal quote, and backslashes not followed by a
+// quote are literal. Inside quotes, a doubled quote is a literal quote.
+// Unlike SplitLine, there are no errors: unterminated quotes simply
+// extend to the end of the line.
+func SplitWindows(s string) []string {
+	if s == "" {
+		return nil
+	}
+
+	// program name
+	var args []string
+	var i int
+	if s[0] == '"' {
+		end := strings.IndexByte(s[1:], '"')
+		if end == -1 {
+			return []string{s[1:]}
+		}
+		args = append(args, s[1:1+end])
+		i = end + 2
+	} else {
+		for i < len(s) && s[i] != ' ' && s[i] != '\t' {
+			i++
+		}
+		args = append(args, s[:i])
+	}
+
+	var word strings.Builder
+	inword := false
+	quoted := false
+	for ; i < len(s); i++ {
+		switch c := s[i]; c {
+
+		case ' ', '\t':
+			if quoted {
+				word.WriteByte(c)
+			} else if inword {
+				args = append(args, word.String())
+				word.Reset()
+				inword = false
+			}
+
+		case '\\':
+			n := 1
+			for i+n < len(s) && s[i+n] == '\\' {
+				n++
+			}
+			i += n - 1
+			if i+1 < len(s) && s[i+1] == '"' {
+				word.WriteString(strings.Repeat(`\`, n/2))
+				if n%2 == 1 {
+					word.WriteByte('"')
+					i++
+				}
+			} else {
+				word.WriteString(strings.Repeat(`\`, n))
+			}
+			inword = true
+
+		case '"':
+			if quoted && i+1 < len(s) && s[i+1] == '"' {
+				word.WriteByte('"')
+				i++
+			} else {
+				quoted = !quoted
+			}
+			inword = true
+
+		default:
+			word.WriteByte(c)
+			inword = true
+		}
+	}
+	if inword {
+		args = append(args, word.String())
+	}
+	return args
+}
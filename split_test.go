@@ -0,0 +1,86 @@
+package optparse
+
+import (
+	"testing"
+)
+
+func TestSplitLine(t *testing.T) {
+	table := []struct {
+		line string
+		want []string
+		err  error
+	}{
+		{"", nil, nil},
+		{"  \t\n ", nil, nil},
+		{"-a -b  foo", []string{"-a", "-b", "foo"}, nil},
+		{`--color='light blue'`, []string{"--color=light blue"}, nil},
+		{`'a'"b"c`, []string{"abc"}, nil},
+		{`'' ""`, []string{"", ""}, nil},
+		{`'\' "\"\$\a"`, []string{`\`, `"$\a`}, nil},
+		{`a\ b c\\`, []string{"a b", `c\`}, nil},
+		{"a\\\nb \"c\\\nd\"", []string{"ab", "cd"}, nil},
+		{"π'π'", []string{"ππ"}, nil},
+		{`'foo`, nil, errQuote},
+		{`"foo\"`, nil, errQuote},
+		{`foo\`, nil, errBackslash},
+	}
+
+	for _, row := range table {
+		got, err := SplitLine(row.line)
+		if err != row.err {
+			t.Errorf("SplitLine(%q), got %v, want %v", row.line, err, row.err)
+		} else if !equal(got, row.want) {
+			t.Errorf("SplitLine(%q), got %q, want %q", row.line, got, row.want)
+		}
+	}
+}
+
+func TestQuote(t *testing.T) {
+	table := []struct {
+		args []string
+		want string
+	}{
+		{nil, ""},
+		{[]string{"cp", "-v", "--", "a.txt", "b/"}, "cp -v -- a.txt b/"},
+		{[]string{"x", ""}, "x ''"},
+		{[]string{"x", "--color=light blue"}, "x '--color=light blue'"},
+		{[]string{"x", "it's", `$HOME`, "*"}, `x 'it'\''s' '$HOME' '*'`},
+		{[]string{"A=1", "A=1"}, "'A=1' A=1"},
+		{[]string{"x", "a\nb", "π"}, "x 'a\nb' 'π'"},
+	}
+	for _, row := range table {
+		got := Quote(row.args)
+		if got != row.want {
+			t.Errorf("Quote(%q), got %q, want %q", row.args, got, row.want)
+		}
+		if back, err := SplitLine(got); err != nil || !equal(back, row.args) {
+			t.Errorf("SplitLine(Quote(%q)), got %q, %v", row.args, back, err)
+		}
+	}
+}
+
+func TestSplitWindows(t *testing.T) {
+	table := []struct {
+		line string
+		want []string
+	}{
+		{``, nil},
+		{`prog`, []string{"prog"}},
+		{`"C:\Program Files\prog.exe" -a`, []string{`C:\Program Files\prog.exe`, "-a"}},
+		{`C:\prog\ -a`, []string{`C:\prog\`, "-a"}},
+		{`prog "a b" c`, []string{"prog", "a b", "c"}},
+		{`prog a\\\b d"e f"g h`, []string{"prog", `a\\\b`, "de fg", "h"}},
+		{`prog a\\\"b c d`, []string{"prog", `a\"b`, "c", "d"}},
+		{`prog a\\\\"b c" d e`, []string{"prog", `a\\b c`, "d", "e"}},
+		{`prog "a""b" ""`, []string{"prog", `a"b`, ""}},
+		{`prog "unterminated  `, []string{"prog", "unterminated  "}},
+		{` -a`, []string{"", "-a"}},
+	}
+
+	for _, row := range table {
+		got := SplitWindows(row.line)
+		if !equal(got, row.want) {
+			t.Errorf("SplitWindows(%q), got %q, want %q", row.line, got, row.want)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+// This is free and unencumbered software released into the public domain.
+
+package optparse
+
+import "errors"
+
+// StripOptions returns a copy of args with the given options, and their
+// arguments, removed, preserving everything else in order, such as for a
+// wrapper that handles its own options and executes a program with the
+// rest. Unrecognized options are kept, with unrecognized short options
+// regrouped in their clusters, as are args[0], any "--", and the
+// arguments following the options. A misused recognized option, such
+// as one missing its argument, is an error. Options are neither loaded
+// nor prompted for.
+func StripOptions(options []Option, args []string) ([]string, error) {
+	if len(args) == 0 {
+		return nil, nil
+	}
+	p := NewParser(options, args)
+	p.peeking = true // no loading or prompting
+	kept := []string{args[0]}
+	keep := func(arg string) { kept = append(kept, arg) }
+	err := p.sift(func(Result) {}, keep, func(err error) error { return err })
+	if err != nil {
+		return nil, err
+	}
+	if p.terminated {
+		kept = append(kept, p.args[p.Index()-1])
+	}
+	return append(kept, p.Rest()...), nil
+}
+
+// Partition classifies every argument following args[0], without
+// stopping at errors, into the results of recognized options, the
+// unrecognized or misused options, and the operands, such as for
+// analyzing or completing a command line. Unlike Parse, options are
+// recognized after operands, up to a "--". Unrecognized short options
+// are regrouped in their clusters. Options are neither loaded nor
+// prompted for.
+func Partition(options []Option, args []string) (results Results, unknown, operands []string) {
+	if len(args) == 0 {
+		return nil, nil, nil
+	}
+	p := NewParser(options, args)
+	p.peeking = true // no loading or prompting
+	found := func(result Result) { results = append(results, result) }
+	other := func(arg string) { unknown = append(unknown, arg) }
+	misused := func(err error) error {
+		var e Error
+		errors.As(err, &e)
+		unknown = append(unknown, p.args[e.Index:max(p.Index(), e.Index+1)]...)
+		return nil
+	}
+	for {
+		p.sift(found, other, misused)
+		if p.terminated || p.Index() == len(p.args) {
+			break
+		}
+		operands = append(operands, p.args[p.Index()])
+		p.optind, p.done = p.Index()+1, false
+	}
+	return results, unknown, append(operands, p.Rest()...)
+}
+
+// sift parses options until the end of options, passing each result to
+// found and each unrecognized option to unknown, with unrecognized short
+// options regrouped in their clusters. Other errors are passed to fail,
+// stopping with its error, if any.
+func (p *Parser) sift(found func(Result), unknown func(string), fail func(error) error) error {
+	cluster, at := "", -1 // unrecognized short options in args[at]
+	for {
+		result, ok, err := p.next()
+		var e Error
+		invalid := errors.As(err, &e) && e.Err == ErrInvalid
+		if at >= 0 && (!ok && !invalid || p.Index() > at || invalid && e.Index != at) {
+			unknown(cluster)
+			at = -1
+		}
+		switch {
+		case invalid && (e.Long != "" || e.Short == 0):
+			unknown(e.Arg)
+		case invalid && at == e.Index:
+			cluster += string(e.Short)
+		case invalid:
+			cluster, at = "-"+string(e.Short), e.Index
+		case err != nil:
+			if err := fail(err); err != nil {
+				return err
+			}
+		case !ok:
+			return nil
+		default:
+			found(result)
+		}
+		p.skip(err)
+	}
+}
@@ -0,0 +1,70 @@
+package optparse
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestStripOptions(t *testing.T) {
+	options := []Option{
+		{Long: "verbose", Short: 'v', Kind: KindNone},
+		{Long: "output", Short: 'o', Kind: KindRequired},
+		{Long: "color", Kind: KindOptional},
+	}
+	table := []struct {
+		args []string
+		want []string
+		err  error
+	}{
+		{nil, nil, nil},
+		{[]string{"w"}, []string{"w"}, nil},
+		{[]string{"w", "-v", "ls", "-l"}, []string{"w", "ls", "-l"}, nil},
+		{[]string{"w", "-lvo", "out", "-a", "x"}, []string{"w", "-l", "-a", "x"}, nil},
+		{[]string{"w", "-avbo", "out"}, []string{"w", "-ab"}, nil},
+		{[]string{"w", "-ab", "--zz", "-c"}, []string{"w", "-ab", "--zz", "-c"}, nil},
+		{[]string{"w", "-ab", "-vc"}, []string{"w", "-ab", "-c"}, nil},
+		{[]string{"w", "--all=1", "--color", "--output=f", "--verbose"}, []string{"w", "--all=1"}, nil},
+		{[]string{"w", "-v", "--", "-v"}, []string{"w", "--", "-v"}, nil},
+		{[]string{"w", "-x", "-o"}, nil, ErrMissing},
+	}
+	for _, row := range table {
+		got, err := StripOptions(options, row.args)
+		if !errors.Is(err, row.err) {
+			t.Errorf("StripOptions(%q), got error %v, want %v", row.args, err, row.err)
+		} else if !slices.Equal(got, row.want) {
+			t.Errorf("StripOptions(%q), got %q, want %q", row.args, got, row.want)
+		}
+	}
+}
+
+func TestPartition(t *testing.T) {
+	options := []Option{
+		{Long: "verbose", Short: 'v', Kind: KindNone},
+		{Long: "output", Short: 'o', Kind: KindRequired, Type: TypeInt},
+	}
+	table := []struct {
+		args     []string
+		results  []string
+		unknown  []string
+		operands []string
+	}{
+		{nil, nil, nil, nil},
+		{[]string{"", "a", "-v", "b"}, []string{"--verbose"}, nil, []string{"a", "b"}},
+		{[]string{"", "-xvy", "a", "--zz=1", "-o", "2"}, []string{"--verbose", "--output=2"}, []string{"-xy", "--zz=1"}, []string{"a"}},
+		{[]string{"", "-o", "x", "a", "--verbose=1", "-o"}, nil, []string{"-o", "x", "--verbose=1", "-o"}, []string{"a"}},
+		{[]string{"", "a", "--", "-v", "--"}, nil, nil, []string{"a", "-v", "--"}},
+		{[]string{"", "-", "-v"}, []string{"--verbose"}, nil, []string{"-"}},
+	}
+	for _, row := range table {
+		results, unknown, operands := Partition(options, row.args)
+		var got []string
+		for _, result := range results {
+			got = append(got, result.String())
+		}
+		if !slices.Equal(got, row.results) || !slices.Equal(unknown, row.unknown) || !slices.Equal(operands, row.operands) {
+			t.Errorf("Partition(%q), got %q %q %q, want %q %q %q", row.args,
+				got, unknown, operands, row.results, row.unknown, row.operands)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+// This is free and unencumbered software released into the public domain.
+
+package optparse
+
+import (
+	"sort"
+	"strings"
+)
+
+// Suggest returns the candidates plausibly intended by a mistyped name,
+// closest first: those within a small edit distance, counting adjacent
+// transpositions as a single edit, and those the name abbreviates. It is
+// the same matching used for Error.Suggestions, exposed for names the
+// parser does not know about, such as subcommands:
+//
+//	if len(rest) > 0 && !known[rest[0]] {
+//		hints := optparse.Suggest(rest[0], commandNames)
+//		// ... "did you mean status?"
+//	}
+func Suggest(name string, candidates []string) []string {
+	if name == "" {
+		return nil
+	}
+	limit := len([]rune(name)) / 3
+	if limit < 1 {
+		limit = 1
+	}
+
+	type match struct {
+		name string
+		dist int
+	}
+	var matches []match
+	seen := make(map[string]bool)
+	for _, candidate := range candidates {
+		if candidate == "" || candidate == name || seen[candidate] {
+			continue
+		}
+		dist := distance(name, candidate)
+		if dist > limit && !strings.HasPrefix(candidate, name) {
+			continue
+		}
+		seen[candidate] = true
+		matches = append(matches, match{candidate, dist})
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].dist < matches[j].dist
+	})
+	var names []string
+	for _, m := range matches {
+		names = append(names, m.name)
+	}
+	return names
+}
+
+// distance computes the optimal string alignment distance between two
+// strings, by rune.
+func distance(a, b string) int {
+	s, t := []rune(a), []rune(b)
+	d := make([][]int, len(s)+1)
+	for i := range d {
+		d[i] = make([]int, len(t)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(s); i++ {
+		for j := 1; j <= len(t); j++ {
+			cost := 1
+			if s[i-1] == t[j-1] {
+				cost = 0
+			}
+			d[i][j] = d[i-1][j-1] + cost
+			if d[i-1][j]+1 < d[i][j] {
+				d[i][j] = d[i-1][j] + 1
+			}
+			if d[i][j-1]+1 < d[i][j] {
+				d[i][j] = d[i][j-1] + 1
+			}
+			if i > 1 && j > 1 && s[i-1] == t[j-2] && s[i-2] == t[j-1] &&
+				d[i-2][j-2]+1 < d[i][j] {
+				d[i][j] = d[i-2][j-2] + 1
+			}
+		}
+	}
+	return d[len(s)][len(t)]
+}
@@ -0,0 +1,47 @@
+package optparse
+
+import (
+	"testing"
+)
+
+func TestSuggest(t *testing.T) {
+	names := []string{"amend", "brief", "color", "colour", "delay", "erase", ""}
+	table := []struct {
+		name string
+		want []string
+	}{
+		{"colr", []string{"color"}},
+		{"colur", []string{"color", "colour"}},
+		{"clor", []string{"color"}},
+		{"dealy", []string{"delay"}},
+		{"col", []string{"color", "colour"}},
+		{"xyzzy", nil},
+		{"", nil},
+	}
+
+	for _, row := range table {
+		got := Suggest(row.name, names)
+		if !equal(got, row.want) {
+			t.Errorf("Suggest(%q), got %q, want %q", row.name, got, row.want)
+		}
+	}
+}
+
+func TestSuggestCommand(t *testing.T) {
+	commands := []string{"status", "stash", "commit", "checkout"}
+	got := Suggest("stauts", commands)
+	if want := []string{"status"}; !equal(got, want) {
+		t.Errorf("Suggest(%q), got %q, want %q", "stauts", got, want)
+	}
+}
+
+func TestSuggestions(t *testing.T) {
+	_, _, err := Parse(options, []string{"", "--colr"})
+	e, ok := err.(Error)
+	if !ok {
+		t.Fatalf("got %#v, want Error", err)
+	}
+	if want := []string{"color"}; !equal(e.Suggestions, want) {
+		t.Errorf("got %q, want %q", e.Suggestions, want)
+	}
+}
@@ -0,0 +1,13 @@
+// This is free and unencumbered software released into the public domain.
+
+//go:build darwin || freebsd || netbsd || openbsd
+
+package optparse
+
+import "syscall"
+
+// Requests to get and set terminal attributes.
+const (
+	ioctlGetTermios = syscall.TIOCGETA
+	ioctlSetTermios = syscall.TIOCSETA
+)
@@ -0,0 +1,11 @@
+// This is free and unencumbered software released into the public domain.
+
+package optparse
+
+import "syscall"
+
+// Requests to get and set terminal attributes.
+const (
+	ioctlGetTermios = syscall.TCGETS
+	ioctlSetTermios = syscall.TCSETS
+)
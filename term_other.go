@@ -0,0 +1,22 @@
+// This is free and unencumbered software released into the public domain.
+
+//go:build !(linux || darwin || freebsd || netbsd || openbsd)
+
+package optparse
+
+import (
+	"errors"
+	"os"
+)
+
+// fileWidth returns zero, as terminal size is not supported on this
+// platform.
+func fileWidth(*os.File) int {
+	return 0
+}
+
+// readPassword fails, as disabling echo is not supported on this
+// platform.
+func readPassword(*os.File) (string, error) {
+	return "", errors.New("reading secrets is not supported on this platform")
+}
@@ -0,0 +1,65 @@
+// This is free and unencumbered software released into the public domain.
+
+//go:build linux || darwin || freebsd || netbsd || openbsd
+
+package optparse
+
+import (
+	"io"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// fileWidth returns the width of the terminal f, or zero if it is not a
+// terminal.
+func fileWidth(f *os.File) int {
+	var size struct{ rows, cols, xpixel, ypixel uint16 }
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(),
+		uintptr(syscall.TIOCGWINSZ), uintptr(unsafe.Pointer(&size)))
+	if errno != 0 {
+		return 0
+	}
+	return int(size.cols)
+}
+
+// readPassword reads a line from the terminal f with echo disabled.
+func readPassword(f *os.File) (string, error) {
+	var old syscall.Termios
+	if err := termios(f, ioctlGetTermios, &old); err != nil {
+		return "", err
+	}
+	t := old
+	t.Lflag &^= syscall.ECHO
+	t.Lflag |= syscall.ICANON | syscall.ISIG
+	if err := termios(f, ioctlSetTermios, &t); err != nil {
+		return "", err
+	}
+	defer termios(f, ioctlSetTermios, &old)
+
+	// read a byte at a time so as not to consume beyond the line
+	var line []byte
+	var b [1]byte
+	for {
+		n, err := f.Read(b[:])
+		switch {
+		case n > 0 && b[0] == '\n':
+			return string(line), nil
+		case n > 0 && b[0] != '\r':
+			line = append(line, b[0])
+		case err == io.EOF && len(line) > 0:
+			return string(line), nil
+		case err != nil:
+			return "", err
+		}
+	}
+}
+
+// termios gets or sets the terminal attributes of f.
+func termios(f *os.File, request uintptr, t *syscall.Termios) error {
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), request, uintptr(unsafe.Pointer(t)))
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
@@ -0,0 +1,197 @@
+// This is free and unencumbered software released into the public domain.
+
+package optparse
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ParseTOML is like ParseINI but reads a TOML config file. A key within
+// a table names the option by the dotted path of the table and key, so
+// delay in table [net] sets --net.delay. Arrays give repeated options,
+// and booleans set options without an argument. Numbers and dates are
+// passed as written. Multi-line strings, multi-line arrays, and arrays
+// of tables are not supported.
+func ParseTOML(r io.Reader, options []Option) (Results, error) {
+	return parseConfig(readTOML, r, options)
+}
+
+// MergeTOML merges a TOML config file into the invocation's results,
+// with the command line winning as with Results.Merge. Top-level keys
+// configure the root command, and a table named by the path of selected
+// subcommands, such as [remote.add], configures the last of them. Tables
+// for subcommands not selected are ignored, and other tables name
+// dotted options as in ParseTOML. Persistent options may also be set
+// within the tables of descendant commands.
+func (inv *Invocation) MergeTOML(r io.Reader) error {
+	entries, err := readTOML(r)
+	if err != nil {
+		return err
+	}
+	return inv.merge(entries)
+}
+
+// readTOML reads the entries of a TOML file up to the first error.
+func readTOML(r io.Reader) ([]entry, error) {
+	var entries []entry
+	var table []string
+	s := bufio.NewScanner(r)
+	for line := 1; s.Scan(); line++ {
+		fail := func(err error) ([]entry, error) {
+			return entries, ConfigError{Line: line, Err: err}
+		}
+		text := strings.TrimSpace(s.Text())
+		switch {
+		case comment(text):
+			continue
+		case strings.HasPrefix(text, "[["):
+			return fail(errors.New("arrays of tables are not supported"))
+		case text[0] == '[':
+			keys, rest, err := tomlKey(text[1:])
+			if err != nil {
+				return fail(err)
+			}
+			if !strings.HasPrefix(rest, "]") || !comment(strings.TrimSpace(rest[1:])) {
+				return fail(fmt.Errorf("invalid table %q", text))
+			}
+			table = keys
+			continue
+		}
+
+		keys, rest, err := tomlKey(text)
+		if err != nil {
+			return fail(err)
+		}
+		if !strings.HasPrefix(rest, "=") {
+			return fail(fmt.Errorf("expected = after key %q", strings.Join(keys, ".")))
+		}
+		values, rest, err := tomlValue(strings.TrimSpace(rest[1:]), true)
+		if err != nil {
+			return fail(err)
+		}
+		if !comment(rest) {
+			return fail(fmt.Errorf("unexpected %q", rest))
+		}
+		path := append(table[:len(table):len(table)], keys...)
+		for _, value := range values {
+			entries = append(entries, entry{path, value, true, line})
+		}
+	}
+	return entries, s.Err()
+}
+
+// comment reports whether trimmed text is blank or a comment.
+func comment(text string) bool {
+	return text == "" || text[0] == '#'
+}
+
+// tomlKey parses a possibly dotted key, returning its parts and the
+// remaining text with leading space removed.
+func tomlKey(s string) ([]string, string, error) {
+	var keys []string
+	for {
+		s = strings.TrimSpace(s)
+		var key string
+		var err error
+		switch {
+		case strings.HasPrefix(s, `"`) || strings.HasPrefix(s, "'"):
+			key, s, err = tomlString(s)
+			if err != nil {
+				return nil, s, err
+			}
+		default:
+			n := strings.IndexFunc(s, func(r rune) bool {
+				return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' ||
+					r >= '0' && r <= '9' || r == '_' || r == '-')
+			})
+			if n < 0 {
+				n = len(s)
+			}
+			if n == 0 {
+				return nil, s, fmt.Errorf("invalid key %q", s)
+			}
+			key, s = s[:n], s[n:]
+		}
+		keys = append(keys, key)
+		s = strings.TrimSpace(s)
+		if !strings.HasPrefix(s, ".") {
+			return keys, s, nil
+		}
+		s = s[1:]
+	}
+}
+
+// tomlString parses a basic or literal string at the start of s,
+// returning the remaining text.
+func tomlString(s string) (string, string, error) {
+	if strings.HasPrefix(s, `"""`) || strings.HasPrefix(s, "'''") {
+		return "", s, errors.New("multi-line strings are not supported")
+	}
+	if s[0] == '\'' {
+		end := strings.IndexByte(s[1:], '\'')
+		if end < 0 {
+			return "", s, fmt.Errorf("unterminated string %s", s)
+		}
+		return s[1 : end+1], s[end+2:], nil
+	}
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			value, err := strconv.Unquote(s[:i+1])
+			if err != nil {
+				return "", s, fmt.Errorf("invalid string %s", s[:i+1])
+			}
+			return value, s[i+1:], nil
+		}
+	}
+	return "", s, fmt.Errorf("unterminated string %s", s)
+}
+
+// tomlValue parses a value at the start of s, returning each element if
+// it is an array, and the remaining text with leading space removed.
+// Arrays may not nest.
+func tomlValue(s string, array bool) ([]string, string, error) {
+	switch {
+	case s == "":
+		return nil, s, errors.New("missing value")
+	case s[0] == '"' || s[0] == '\'':
+		value, rest, err := tomlString(s)
+		return []string{value}, strings.TrimSpace(rest), err
+	case s[0] == '[' && array:
+		var values []string
+		s = strings.TrimSpace(s[1:])
+		for !strings.HasPrefix(s, "]") {
+			value, rest, err := tomlValue(s, false)
+			if err != nil {
+				return nil, rest, err
+			}
+			values = append(values, value...)
+			switch {
+			case strings.HasPrefix(rest, ","):
+				s = strings.TrimSpace(rest[1:])
+			case strings.HasPrefix(rest, "]"):
+				s = rest
+			default:
+				return nil, rest, errors.New("unterminated array")
+			}
+		}
+		return values, strings.TrimSpace(s[1:]), nil
+	case s[0] == '[' || s[0] == '{':
+		return nil, s, fmt.Errorf("unsupported value %s", s)
+	}
+	n := strings.IndexAny(s, " \t,]#")
+	switch {
+	case n < 0:
+		n = len(s)
+	case n == 0:
+		return nil, s, errors.New("missing value")
+	}
+	return []string{s[:n]}, strings.TrimSpace(s[n:]), nil
+}
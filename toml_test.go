@@ -0,0 +1,135 @@
+package optparse
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseTOML(t *testing.T) {
+	options := []Option{
+		{Long: "color", Kind: KindOptional},
+		{Long: "verbose", Short: 'v', Kind: KindNone},
+		{Long: "quiet", Kind: KindNone},
+		{Long: "jobs", Kind: KindRequired, Type: TypeInt},
+		{Long: "include", Short: 'I', Kind: KindRequired},
+		{Long: "net.delay", Kind: KindRequired},
+		{Long: "net.proxy url", Kind: KindRequired},
+	}
+	input := `# comment
+color = "al\"ways" # trailing
+verbose = true
+quiet = false
+jobs = 4
+include = ['a', "b",]
+[ net ]
+delay = 10
+"proxy url" = ""
+`
+	results, err := ParseTOML(strings.NewReader(input), options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct {
+		long, optarg string
+		line         int
+	}{
+		{"color", `al"ways`, 2},
+		{"verbose", "", 3},
+		{"jobs", "4", 5},
+		{"include", "a", 6},
+		{"include", "b", 6},
+		{"net.delay", "10", 8},
+		{"net.proxy url", "", 9},
+	}
+	if len(results) != len(want) {
+		t.Fatalf("ParseTOML(), got %d results, want %d", len(results), len(want))
+	}
+	for i, w := range want {
+		r := results[i]
+		if r.Long != w.long || r.Optarg != w.optarg || r.Index != w.line {
+			t.Errorf("ParseTOML()[%d], got %q %q %d, want %q %q %d",
+				i, r.Long, r.Optarg, r.Index, w.long, w.optarg, w.line)
+		}
+	}
+
+	errs := []struct {
+		input string
+		msg   string
+	}{
+		{"bogus = 1", "line 1: invalid option: --bogus"},
+		{"\njobs", `line 2: expected = after key "jobs"`},
+		{"jobs = ", "line 1: missing value"},
+		{"jobs = 1 2", `line 1: unexpected "2"`},
+		{"color = 'x", "line 1: unterminated string 'x"},
+		{`color = """x"""`, "line 1: multi-line strings are not supported"},
+		{"include = [1, [2]]", "line 1: unsupported value [2]]"},
+		{"include = [1, 2", "line 1: unterminated array"},
+		{"[[net]]", "line 1: arrays of tables are not supported"},
+		{"[net", `line 1: invalid table "[net"`},
+		{"verbose = 1.5", `line 1: invalid boolean "1.5": --verbose (-v)`},
+	}
+	for _, test := range errs {
+		_, err := ParseTOML(strings.NewReader(test.input), options)
+		var cerr ConfigError
+		if !errors.As(err, &cerr) || err.Error() != test.msg {
+			t.Errorf("ParseTOML(%q), got %v, want %q", test.input, err, test.msg)
+		}
+	}
+}
+
+func TestMergeTOML(t *testing.T) {
+	add := &Command{
+		Name:    "add",
+		Options: []Option{{Long: "fetch", Short: 'f', Kind: KindNone}},
+	}
+	remote := &Command{
+		Name:     "remote",
+		Options:  []Option{{Long: "verbose", Short: 'v', Kind: KindNone}},
+		Commands: []*Command{add, {Name: "remove"}},
+	}
+	root := &Command{
+		Name: "git",
+		Options: []Option{
+			{Long: "color", Kind: KindRequired, Persistent: true},
+			{Long: "pager", Kind: KindRequired},
+		},
+		Commands: []*Command{remote, {Name: "log"}},
+	}
+	inv, err := root.Parse([]string{"git", "--pager=less", "remote", "add", "-f"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	input := `pager = "more"
+color = "never"
+[log]
+bogus = 1
+[remote]
+verbose = true
+remove.bogus = 1
+[remote.add]
+fetch = false
+color = "always"
+`
+	if err := inv.MergeTOML(strings.NewReader(input)); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := inv.Results[0].Get("pager"); got != "less" {
+		t.Errorf(`Get("pager"), got %q, want "less"`, got)
+	}
+	if got := inv.Results[0].GetAll("color"); !equal(got, []string{"never", "always"}) {
+		t.Errorf(`GetAll("color"), got %q`, got)
+	}
+	if !inv.Results[1].Has("verbose") {
+		t.Errorf("MergeTOML(), remote missing --verbose")
+	}
+	if !inv.Results[2].Has("fetch") {
+		t.Errorf("MergeTOML(), add missing --fetch")
+	}
+
+	err = inv.MergeTOML(strings.NewReader("[remote]\nbogus = 1\n"))
+	if !errors.Is(err, ErrInvalid) {
+		t.Errorf("MergeTOML(), got %v, want %v", err, ErrInvalid)
+	}
+}
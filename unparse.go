@@ -0,0 +1,37 @@
+// This is free and unencumbered software released into the public domain.
+
+package optparse
+
+import "strings"
+
+// Unparse returns canonical arguments equivalent to the results
+// followed by the remaining arguments, such as for re-executing the
+// program or showing the effective command. Options are given by long
+// name when they have one, with any argument attached (--jobs=4), and
+// short options take a required argument separately (-o file). A "--"
+// precedes rest when its first argument would otherwise be mistaken for
+// an option. As with the arguments to Parse, the program name must be
+// prepended. Sensitive arguments are not masked.
+func Unparse(results []Result, rest []string) []string {
+	var args []string
+	for _, result := range results {
+		hasArg := result.Kind == KindRequired || result.Attached || result.Optarg != ""
+		name := result.flag()
+		switch {
+		case result.Kind == KindUntil:
+			args = append(append(append(args, name), result.Optargs...), result.until())
+		case strings.HasPrefix(name, "--") && hasArg:
+			args = append(args, name+"="+result.Optarg)
+		case strings.HasPrefix(name, "--"):
+			args = append(args, name)
+		case result.Kind == KindRequired:
+			args = append(args, name, result.Optarg)
+		default:
+			args = append(args, name+result.Optarg)
+		}
+	}
+	if len(rest) > 0 && strings.HasPrefix(rest[0], "-") && rest[0] != "-" {
+		args = append(args, "--")
+	}
+	return append(args, rest...)
+}
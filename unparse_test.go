@@ -0,0 +1,52 @@
+package optparse
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestUnparse(t *testing.T) {
+	options := []Option{
+		{Long: "verbose", Short: 'v', Kind: KindNone},
+		{Long: "color", Short: 'c', Kind: KindOptional},
+		{Long: "jobs", Short: 'j', Kind: KindRequired},
+		{Short: 'o', Kind: KindRequired},
+		{Short: 'x', Kind: KindOptional},
+		{Short: 'q', Kind: KindNone},
+		{Short: 'e', Kind: KindUntil},
+	}
+	table := []struct {
+		args []string
+		want []string
+	}{
+		{[]string{""}, nil},
+		{[]string{"", "-vvq"}, []string{"--verbose", "--verbose", "-q"}},
+		{[]string{"", "-j", "4", "-c", "file"}, []string{"--jobs=4", "--color", "file"}},
+		{[]string{"", "-cauto", "--color="}, []string{"--color=auto", "--color="}},
+		{[]string{"", "-ofile", "-o", ""}, []string{"-o", "file", "-o", ""}},
+		{[]string{"", "-x1", "-x"}, []string{"-x1", "-x"}},
+		{[]string{"", "-ea", "b", ";", "-q"}, []string{"-e", "a", "b", ";", "-q"}},
+		{[]string{"", "-q", "--", "-v"}, []string{"-q", "--", "-v"}},
+		{[]string{"", "--", "--"}, []string{"--", "--"}},
+		{[]string{"", "--", "-", "-v"}, []string{"-", "-v"}},
+	}
+	for _, row := range table {
+		results, rest, err := Parse(options, row.args)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := Unparse(results, rest)
+		if !slices.Equal(got, row.want) {
+			t.Errorf("Unparse(%q), got %q, want %q", row.args, got, row.want)
+		}
+
+		// parsing again gives the same results
+		again, againRest, err := Parse(options, append([]string{""}, got...))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(Unparse(again, againRest), got) {
+			t.Errorf("Unparse(%q), got %q, which is not stable", row.args, got)
+		}
+	}
+}
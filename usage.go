@@ -0,0 +1,408 @@
+// This is free and unencumbered software released into the public domain.
+
+package optparse
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode/utf8"
+)
+
+// Usage writes a listing of the options, one per line, in the style of
+// GNU --help output, with each option's Help text aligned in a column:
+//
+//	-a, --amend            amend the previous commit
+//	-c, --color[=ARG]      colorize the output
+//	-d, --delay=ARG        wait before starting
+//
+// Options with a Group are listed after the others under a heading for
+// each group, in order of first appearance. Help text is wrapped to the
+// width of the terminal, if w is one, or else to the COLUMNS
+// environment variable or 80 columns.
+func Usage(w io.Writer, options []Option) error {
+	return Style{}.Usage(w, options)
+}
+
+// UsageWidth is like Usage but wraps Help text to the given width.
+func UsageWidth(w io.Writer, options []Option, width int) error {
+	return Style{Width: width}.Usage(w, options)
+}
+
+// Usage writes help text for the command: a usage line, its options,
+// and its subcommands. Subcommands are listed under "Commands:" unless
+// assigned a Group, each of which gets its own section in order of
+// first appearance, as in git or kubectl help.
+func (c *Command) Usage(w io.Writer) error {
+	return Style{}.CommandUsage(w, c)
+}
+
+// Style adjusts help text. The zero value selects the behavior of the
+// package-level Usage.
+type Style struct {
+	// Width is the line width for wrapping Help text. If zero, it is
+	// the width of the terminal, if writing to one, or else the COLUMNS
+	// environment variable or 80.
+	Width int
+
+	// Color selects highlighting of option names, argument
+	// placeholders, and headings with terminal escape sequences.
+	Color Color
+
+	// Template, if not nil, produces the help text from a HelpData in
+	// place of the built-in layout, such as to add sections like
+	// EXAMPLES. Width and Color do not apply.
+	Template *template.Template
+
+	// Translate, if not nil, returns the text to show in place of each
+	// piece of help text, such as from a message catalog for the active
+	// locale. Option Help is keyed by the option's name, such as
+	// "--delay", or "-d" if it has no long name. Command and operand
+	// Help is keyed by Name, and headings and group titles by their
+	// untranslated text, such as "Options".
+	Translate func(key, text string) string
+}
+
+// Color is an enumeration of Style.Color settings.
+type Color int
+
+const (
+	// ColorNever writes plain text.
+	ColorNever Color = iota
+	// ColorAuto highlights when writing to a terminal unless the
+	// NO_COLOR environment variable is set.
+	ColorAuto
+	// ColorAlways highlights unconditionally.
+	ColorAlways
+)
+
+// Escape sequences for highlighting.
+const (
+	bold      = "\x1b[1m"
+	underline = "\x1b[4m"
+	reset     = "\x1b[0m"
+)
+
+// Usage is like the package-level Usage but styled.
+func (s Style) Usage(w io.Writer, options []Option) error {
+	data := s.translate(NewHelpData(&Command{Options: options}))
+	if s.Template != nil {
+		return s.Template.Execute(w, data)
+	}
+	r := s.renderer(w)
+	r.options(data.Options)
+	return r.Flush()
+}
+
+// CommandUsage is like Command.Usage but styled.
+func (s Style) CommandUsage(w io.Writer, c *Command) error {
+	data := s.translate(NewHelpData(c))
+	if s.Template != nil {
+		return s.Template.Execute(w, data)
+	}
+
+	r := s.renderer(w)
+	fmt.Fprintf(r, "%s %s [OPTION]...", r.paint(bold, s.text("Usage", "Usage")+":"), c.Name)
+	if len(c.Commands) > 0 {
+		fmt.Fprint(r, " COMMAND [ARG]...")
+	} else if len(c.Operands) > 0 {
+		fmt.Fprint(r, " "+operandSynopsis(c.Operands))
+	}
+	fmt.Fprintln(r)
+	if len(c.Options) > 0 {
+		fmt.Fprintf(r, "\n%s\n", r.paint(bold, s.text("Options", "Options")+":"))
+		r.options(data.Options)
+	}
+	if slices.ContainsFunc(data.Operands, func(o Operand) bool { return o.Help != "" }) {
+		fmt.Fprintf(r, "\n%s\n", r.paint(bold, s.text("Arguments", "Arguments")+":"))
+		width := 0
+		for _, operand := range data.Operands {
+			width = max(width, utf8.RuneCountInString(operand.Name))
+		}
+		for _, operand := range data.Operands {
+			n := utf8.RuneCountInString(operand.Name)
+			line := fmt.Sprintf("  %s%*s  %s", r.paint(bold, operand.Name), width-n, "", operand.Help)
+			fmt.Fprintln(r, strings.TrimRight(line, " "))
+		}
+	}
+
+	width := 0
+	for _, sub := range c.Commands {
+		width = max(width, utf8.RuneCountInString(sub.Name))
+	}
+	for _, group := range data.Commands {
+		title := group.Title
+		if title == "" {
+			title = s.text("Commands", "Commands")
+		}
+		fmt.Fprintf(r, "\n%s\n", r.paint(bold, title+":"))
+		for _, sub := range group.Commands {
+			name := r.paint(bold, sub.Name)
+			if sub.Help == "" {
+				fmt.Fprintf(r, "  %s\n", name)
+				continue
+			}
+			n := utf8.RuneCountInString(sub.Name)
+			fmt.Fprintf(r, "  %s%*s  %s\n", name, width-n, "", sub.Help)
+		}
+	}
+	return r.Flush()
+}
+
+// text returns the translation of text under key, if any.
+func (s Style) text(key, text string) string {
+	if s.Translate == nil {
+		return text
+	}
+	return s.Translate(key, text)
+}
+
+// translate returns a copy of data with its help text translated.
+func (s Style) translate(data HelpData) HelpData {
+	if s.Translate == nil {
+		return data
+	}
+	if data.Help != "" {
+		data.Help = s.text(data.Name, data.Help)
+	}
+
+	operands := make([]Operand, len(data.Operands))
+	for i, operand := range data.Operands {
+		if operand.Help != "" {
+			operand.Help = s.text(operand.Name, operand.Help)
+		}
+		operands[i] = operand
+	}
+	data.Operands = operands
+
+	groups := make([]OptionGroup, len(data.Options))
+	for i, group := range data.Options {
+		if group.Title != "" {
+			group.Title = s.text(group.Title, group.Title)
+		}
+		options := make([]HelpOption, len(group.Options))
+		for j, option := range group.Options {
+			if option.Help != "" {
+				key := "--" + option.Long
+				if option.Long == "" {
+					key = "-" + string(option.Short)
+				}
+				option.Help = s.text(key, option.Help)
+			}
+			options[j] = option
+		}
+		group.Options = options
+		groups[i] = group
+	}
+	data.Options = groups
+
+	commands := make([]CommandGroup, len(data.Commands))
+	for i, group := range data.Commands {
+		if group.Title != "" {
+			group.Title = s.text(group.Title, group.Title)
+		}
+		subs := make([]*Command, len(group.Commands))
+		for j, sub := range group.Commands {
+			copied := *sub
+			if copied.Help != "" {
+				copied.Help = s.text(copied.Name, copied.Help)
+			}
+			subs[j] = &copied
+		}
+		group.Commands = subs
+		commands[i] = group
+	}
+	data.Commands = commands
+	return data
+}
+
+// renderer writes styled help text.
+type renderer struct {
+	*bufio.Writer
+	width int
+	color bool
+}
+
+func (s Style) renderer(w io.Writer) renderer {
+	r := renderer{Writer: bufio.NewWriter(w), width: s.Width}
+	if r.width == 0 {
+		r.width = terminalWidth(w)
+	}
+	switch s.Color {
+	case ColorAuto:
+		f, ok := w.(*os.File)
+		r.color = ok && fileWidth(f) > 0 && os.Getenv("NO_COLOR") == ""
+	case ColorAlways:
+		r.color = true
+	}
+	return r
+}
+
+// paint highlights s if enabled.
+func (r renderer) paint(code, s string) string {
+	if !r.color || s == "" {
+		return s
+	}
+	return code + s + reset
+}
+
+// options writes the option listing.
+func (r renderer) options(groups []OptionGroup) {
+	const maxWidth = 24
+	width := 0
+	for _, group := range groups {
+		for _, option := range group.Options {
+			if n := utf8.RuneCountInString(option.Synopsis); n > width && n <= maxWidth {
+				width = n
+			}
+		}
+	}
+
+	indent := strings.Repeat(" ", 2+width+2)
+	for i, group := range groups {
+		if group.Title != "" {
+			if i > 0 {
+				fmt.Fprintln(r)
+			}
+			fmt.Fprintf(r, "%s\n", r.paint(bold, group.Title+":"))
+		}
+		for _, option := range group.Options {
+			names, arg := synopsisParts(option.Option)
+			trimmed := strings.TrimLeft(names, " ")
+			placeholder := strings.TrimRight(strings.TrimLeft(arg, " =["), "]")
+			s := names[:len(names)-len(trimmed)] + r.paint(bold, trimmed) +
+				strings.Replace(arg, placeholder, r.paint(underline, placeholder), 1)
+			help := describe(option.Option)
+			lines := wrap(help, r.width-len(indent))
+			switch n := utf8.RuneCountInString(option.Synopsis); {
+			case help == "":
+				fmt.Fprintf(r, "  %s\n", s)
+			case n > width:
+				fmt.Fprintf(r, "  %s\n%s%s\n", s, indent, lines[0])
+			default:
+				fmt.Fprintf(r, "  %s%*s  %s\n", s, width-n, "", lines[0])
+			}
+			for _, line := range lines[min(1, len(lines)):] {
+				fmt.Fprintf(r, "%s%s\n", indent, line)
+			}
+		}
+	}
+}
+
+// describe returns the option's help text followed by its default and
+// environment variable, if any.
+func describe(option Option) string {
+	var parts []string
+	if option.Help != "" {
+		parts = append(parts, option.Help)
+	}
+	if option.Default != "" {
+		parts = append(parts, "(default: "+option.Default+")")
+	}
+	if option.EnvVar != "" {
+		parts = append(parts, "[env: "+option.EnvVar+"]")
+	}
+	return strings.Join(parts, " ")
+}
+
+// wrap breaks text into lines of at most width columns, where possible,
+// at spaces. Narrow widths are treated as 20 columns.
+func wrap(text string, width int) []string {
+	width = max(width, 20)
+	var lines []string
+	line, n := "", 0
+	for _, word := range strings.Fields(text) {
+		m := utf8.RuneCountInString(word)
+		switch {
+		case n == 0:
+			line, n = word, m
+		case n+1+m > width:
+			lines = append(lines, line)
+			line, n = word, m
+		default:
+			line, n = line+" "+word, n+1+m
+		}
+	}
+	return append(lines, line)
+}
+
+// terminalWidth returns the width of the terminal w, if it is one, or
+// else the COLUMNS environment variable or 80.
+func terminalWidth(w io.Writer) int {
+	if f, ok := w.(*os.File); ok {
+		if width := fileWidth(f); width > 0 {
+			return width
+		}
+	}
+	if width, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && width > 0 {
+		return width
+	}
+	return 80
+}
+
+// synopsis formats the option's names along with a placeholder for its
+// argument.
+func synopsis(option Option) string {
+	names, arg := synopsisParts(option)
+	return names + arg
+}
+
+// synopsisParts returns the option's names and argument placeholder
+// separately.
+func synopsisParts(option Option) (names, arg string) {
+	switch {
+	case option.Short != 0 && option.Long != "":
+		names = fmt.Sprintf("-%c, --%s", option.Short, option.Long)
+	case option.Short != 0:
+		names = fmt.Sprintf("-%c", option.Short)
+	default:
+		names = fmt.Sprintf("    --%s", option.Long)
+	}
+
+	name := option.ArgName
+	if name == "" {
+		name = "ARG"
+	}
+	switch {
+	case option.Kind == KindRequired && option.Long != "":
+		arg = "=" + name
+	case option.Kind == KindRequired:
+		arg = " " + name
+	case option.Kind == KindOptional && option.Long != "":
+		arg = "[=" + name + "]"
+	case option.Kind == KindOptional:
+		arg = "[" + name + "]"
+	case option.Kind == KindUntil:
+		arg = " " + name + "... " + option.until()
+	}
+	return names, arg
+}
+
+// Hooks for testing ExitOnError.
+var (
+	stderr io.Writer = os.Stderr
+	exit             = os.Exit
+)
+
+// ExitOnError is like Parse, but on failure it prints the error and the
+// option usage to standard error, then exits with status 2, like the
+// flag package's ExitOnError.
+func ExitOnError(options []Option, args []string) (Results, []string) {
+	results, rest, err := Parse(options, args)
+	if err != nil {
+		var prog string
+		if len(args) > 0 {
+			prog = args[0]
+		}
+		fmt.Fprintf(stderr, "%s: %s\n", prog, err)
+		fmt.Fprintf(stderr, "Usage: %s [OPTION]...\n", prog)
+		Usage(stderr, options)
+		exit(2)
+	}
+	return results, rest
+}
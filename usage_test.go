@@ -0,0 +1,314 @@
+package optparse
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestUsage(t *testing.T) {
+	var buf bytes.Buffer
+	Usage(&buf, options)
+	want := `  -a, --amend
+  -b, --brief
+  -c, --color[=ARG]
+  -d, --delay=ARG
+  -e, --erase
+  -π, --pi
+      --long
+  -s
+`
+	if got := buf.String(); got != want {
+		t.Errorf("Usage(), got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestExitOnError(t *testing.T) {
+	var buf bytes.Buffer
+	var status int
+	oldStderr, oldExit := stderr, exit
+	stderr = &buf
+	exit = func(code int) { status = code }
+	defer func() {
+		stderr = oldStderr
+		exit = oldExit
+	}()
+
+	results, rest := ExitOnError(options, []string{"prog", "-a", "x"})
+	if len(results) != 1 || !equal(rest, []string{"x"}) || status != 0 {
+		t.Errorf("ExitOnError(), got %v %q %d", results, rest, status)
+	}
+
+	ExitOnError(options, []string{"prog", "-x"})
+	if status != 2 {
+		t.Errorf("ExitOnError(), got status %d, want 2", status)
+	}
+	want := "prog: invalid option: -x\nUsage: prog [OPTION]...\n  -a, --amend\n"
+	if got := buf.String(); len(got) < len(want) || got[:len(want)] != want {
+		t.Errorf("ExitOnError(), got:\n%s", got)
+	}
+}
+
+func TestUsageHelp(t *testing.T) {
+	options := []Option{
+		{Long: "amend", Short: 'a', Kind: KindNone, Help: "amend the previous commit"},
+		{Long: "color", Short: 'c', Kind: KindOptional, Help: "colorize the output"},
+		{Long: "delay", Short: 'd', Kind: KindRequired},
+		{Long: "very-long-option-name", Kind: KindRequired, Help: "long"},
+	}
+	var buf bytes.Buffer
+	Usage(&buf, options)
+	want := `  -a, --amend        amend the previous commit
+  -c, --color[=ARG]  colorize the output
+  -d, --delay=ARG
+      --very-long-option-name=ARG
+                     long
+`
+	if got := buf.String(); got != want {
+		t.Errorf("Usage(), got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestCommandUsage(t *testing.T) {
+	root := &Command{
+		Name:    "app",
+		Options: []Option{{Long: "verbose", Short: 'v', Kind: KindNone, Help: "explain"}},
+		Commands: []*Command{
+			{Name: "init", Help: "create a repository", Group: "Basic Commands"},
+			{Name: "help", Help: "show help"},
+			{Name: "gc", Help: "clean up", Group: "Advanced Commands"},
+			{Name: "status", Group: "Basic Commands"},
+			{Name: "version"},
+		},
+	}
+	var buf bytes.Buffer
+	root.Usage(&buf)
+	want := `Usage: app [OPTION]... COMMAND [ARG]...
+
+Options:
+  -v, --verbose  explain
+
+Commands:
+  help     show help
+  version
+
+Basic Commands:
+  init     create a repository
+  status
+
+Advanced Commands:
+  gc       clean up
+`
+	if got := buf.String(); got != want {
+		t.Errorf("Usage(), got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestUsageWidth(t *testing.T) {
+	options := []Option{
+		{Long: "amend", Short: 'a', Kind: KindNone, Help: "amend the previous commit instead of creating a new one"},
+		{Long: "very-long-option-name", Kind: KindNone, Help: "a long option with long help text"},
+	}
+	var buf bytes.Buffer
+	UsageWidth(&buf, options, 40)
+	want := `  -a, --amend  amend the previous commit
+               instead of creating a new
+               one
+      --very-long-option-name
+               a long option with long
+               help text
+`
+	if got := buf.String(); got != want {
+		t.Errorf("UsageWidth(), got:\n%s\nwant:\n%s", got, want)
+	}
+
+	buf.Reset()
+	t.Setenv("COLUMNS", "50")
+	Usage(&buf, options[:1])
+	want = `  -a, --amend  amend the previous commit instead
+               of creating a new one
+`
+	if got := buf.String(); got != want {
+		t.Errorf("Usage(), got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestUsageGroup(t *testing.T) {
+	options := []Option{
+		{Long: "output", Short: 'o', Kind: KindRequired, Group: "Output options", Help: "write to file"},
+		{Long: "help", Kind: KindNone, Help: "show help"},
+		{Long: "proxy", Kind: KindRequired, Group: "Network options"},
+		{Long: "silent", Short: 's', Kind: KindNone, Group: "Output options"},
+	}
+	var buf bytes.Buffer
+	UsageWidth(&buf, options, 80)
+	want := `      --help        show help
+
+Output options:
+  -o, --output=ARG  write to file
+  -s, --silent
+
+Network options:
+      --proxy=ARG
+`
+	if got := buf.String(); got != want {
+		t.Errorf("Usage(), got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestStyleColor(t *testing.T) {
+	options := []Option{
+		{Long: "color", Short: 'c', Kind: KindOptional, Help: "colorize"},
+		{Long: "long", Kind: KindNone, Group: "Other"},
+	}
+	var buf bytes.Buffer
+	Style{Color: ColorAlways}.Usage(&buf, options)
+	want := "  \x1b[1m-c, --color\x1b[0m[=\x1b[4mARG\x1b[0m]  colorize\n" +
+		"\n\x1b[1mOther:\x1b[0m\n" +
+		"      \x1b[1m--long\x1b[0m\n"
+	if got := buf.String(); got != want {
+		t.Errorf("Usage(), got %q, want %q", got, want)
+	}
+
+	// not a terminal
+	buf.Reset()
+	Style{Color: ColorAuto}.Usage(&buf, options)
+	if bytes.Contains(buf.Bytes(), []byte("\x1b")) {
+		t.Errorf("Usage(), got %q, want no color", buf.String())
+	}
+
+	buf.Reset()
+	root := &Command{Name: "app", Commands: []*Command{{Name: "run", Help: "run it"}}}
+	Style{Color: ColorAlways}.CommandUsage(&buf, root)
+	want = "\x1b[1mUsage:\x1b[0m app [OPTION]... COMMAND [ARG]...\n" +
+		"\n\x1b[1mCommands:\x1b[0m\n" +
+		"  \x1b[1mrun\x1b[0m  run it\n"
+	if got := buf.String(); got != want {
+		t.Errorf("CommandUsage(), got %q, want %q", got, want)
+	}
+}
+
+func TestUsageArgName(t *testing.T) {
+	options := []Option{
+		{Long: "delay", Short: 'd', Kind: KindRequired, ArgName: "SECONDS"},
+		{Long: "color", Kind: KindOptional, ArgName: "WHEN"},
+		{Short: 'o', Kind: KindRequired, ArgName: "FILE"},
+		{Long: "exec", Kind: KindUntil, ArgName: "CMD"},
+	}
+	var buf bytes.Buffer
+	UsageWidth(&buf, options, 80)
+	want := "  -d, --delay=SECONDS\n      --color[=WHEN]\n  -o FILE\n      --exec CMD... ;\n"
+	if got := buf.String(); got != want {
+		t.Errorf("Usage(), got %q, want %q", got, want)
+	}
+
+	buf.Reset()
+	Style{Color: ColorAlways}.Usage(&buf, options[1:2])
+	want = "      \x1b[1m--color\x1b[0m[=\x1b[4mWHEN\x1b[0m]\n"
+	if got := buf.String(); got != want {
+		t.Errorf("Usage(), got %q, want %q", got, want)
+	}
+}
+
+func TestUsageDefault(t *testing.T) {
+	options := []Option{
+		{Long: "color", Kind: KindOptional, Help: "colorize", Default: "auto", EnvVar: "APP_COLOR"},
+		{Long: "jobs", Kind: KindRequired, Default: "4"},
+	}
+	var buf bytes.Buffer
+	UsageWidth(&buf, options, 80)
+	want := "      --color[=ARG]  colorize (default: auto) [env: APP_COLOR]\n" +
+		"      --jobs=ARG     (default: 4)\n"
+	if got := buf.String(); got != want {
+		t.Errorf("Usage(), got %q, want %q", got, want)
+	}
+}
+
+func TestStyleTranslate(t *testing.T) {
+	catalog := map[string]string{
+		"Usage":    "Aufruf",
+		"Options":  "Optionen",
+		"Commands": "Befehle",
+		"Network":  "Netzwerk",
+		"--color":  "Ausgabe einfärben",
+		"-q":       "weniger ausgeben",
+		"run":      "ausführen",
+	}
+	translate := func(key, text string) string {
+		if s, ok := catalog[key]; ok {
+			return s
+		}
+		return text
+	}
+	root := &Command{
+		Name: "app",
+		Options: []Option{
+			{Long: "color", Kind: KindNone, Help: "colorize"},
+			{Short: 'q', Kind: KindNone, Help: "be quiet"},
+			{Long: "proxy", Kind: KindNone, Help: "use a proxy", Group: "Network"},
+		},
+		Commands: []*Command{{Name: "run", Help: "run it"}},
+	}
+	var buf bytes.Buffer
+	Style{Width: 80, Translate: translate}.CommandUsage(&buf, root)
+	want := `Aufruf: app [OPTION]... COMMAND [ARG]...
+
+Optionen:
+      --color  Ausgabe einfärben
+  -q           weniger ausgeben
+
+Netzwerk:
+      --proxy  use a proxy
+
+Befehle:
+  run  ausführen
+`
+	if got := buf.String(); got != want {
+		t.Errorf("CommandUsage(), got:\n%s\nwant:\n%s", got, want)
+	}
+	if root.Commands[0].Help != "run it" {
+		t.Errorf("CommandUsage() modified Help, got %q", root.Commands[0].Help)
+	}
+}
+
+func TestUsageOperands(t *testing.T) {
+	cmd := &Command{
+		Name:    "cp",
+		Options: []Option{{Long: "force", Short: 'f', Kind: KindNone}},
+		Operands: []Operand{
+			{Name: "SOURCE", Required: true, Help: "file to copy"},
+			{Name: "DEST"},
+		},
+	}
+	var buf bytes.Buffer
+	cmd.Usage(&buf)
+	want := `Usage: cp [OPTION]... SOURCE [DEST]
+
+Options:
+  -f, --force
+
+Arguments:
+  SOURCE  file to copy
+  DEST
+`
+	if got := buf.String(); got != want {
+		t.Errorf("Usage(), got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestOperandSynopsis(t *testing.T) {
+	table := []struct {
+		operand Operand
+		want    string
+	}{
+		{Operand{Name: "FILE", Variadic: true}, "[FILE]..."},
+		{Operand{Name: "FILE", Variadic: true, Required: true}, "FILE..."},
+		{Operand{Name: "FILE", Variadic: true, Min: 2}, "FILE..."},
+		{Operand{Name: "FILE", Required: true}, "FILE"},
+		{Operand{Name: "FILE"}, "[FILE]"},
+	}
+	for _, test := range table {
+		if got := operandSynopsis([]Operand{test.operand}); got != test.want {
+			t.Errorf("operandSynopsis(%v), got %q, want %q", test.operand, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+// This is free and unencumbered software released into the public domain.
+
+package optparse
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"runtime/debug"
+	"strings"
+)
+
+// Hooks for testing VersionOption.
+var (
+	stdout        io.Writer = os.Stdout
+	readBuildInfo           = debug.ReadBuildInfo
+)
+
+// Version returns a version banner for the program built from the
+// module version and version control information recorded by the Go
+// toolchain:
+//
+//	prog v1.2.3 (revision 0123456789ab, 2024-05-01T12:00:00Z, modified)
+//
+// Information that is unavailable is omitted.
+func Version(prog string) string {
+	info, ok := readBuildInfo()
+	if !ok {
+		return prog
+	}
+	banner := prog
+	if v := info.Main.Version; v != "" && v != "(devel)" {
+		banner += " " + v
+	}
+
+	var details []string
+	for _, setting := range info.Settings {
+		switch {
+		case setting.Key == "vcs.revision" && len(setting.Value) > 12:
+			details = append(details, "revision "+setting.Value[:12])
+		case setting.Key == "vcs.revision":
+			details = append(details, "revision "+setting.Value)
+		case setting.Key == "vcs.time":
+			details = append(details, setting.Value)
+		case setting.Key == "vcs.modified" && setting.Value == "true":
+			details = append(details, "modified")
+		}
+	}
+	if details != nil {
+		banner += " (" + strings.Join(details, ", ") + ")"
+	}
+	return banner
+}
+
+// VersionOption returns a --version option whose Func, as used by Run
+// and Set, prints the Version banner to standard output and exits.
+func VersionOption(prog string) Option {
+	return Option{
+		Long: "version",
+		Kind: KindNone,
+		Help: "output version information and exit",
+		Func: func(string) error {
+			fmt.Fprintln(stdout, Version(prog))
+			exit(0)
+			return nil
+		},
+	}
+}
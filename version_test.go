@@ -0,0 +1,69 @@
+package optparse
+
+import (
+	"bytes"
+	"runtime/debug"
+	"testing"
+)
+
+func TestVersion(t *testing.T) {
+	defer func(old func() (*debug.BuildInfo, bool)) { readBuildInfo = old }(readBuildInfo)
+
+	table := []struct {
+		info *debug.BuildInfo
+		want string
+	}{
+		{nil, "prog"},
+		{&debug.BuildInfo{Main: debug.Module{Version: "(devel)"}}, "prog"},
+		{&debug.BuildInfo{Main: debug.Module{Version: "v1.2.3"}}, "prog v1.2.3"},
+		{
+			&debug.BuildInfo{
+				Main: debug.Module{Version: "v1.2.3"},
+				Settings: []debug.BuildSetting{
+					{Key: "vcs.revision", Value: "0123456789abcdef"},
+					{Key: "vcs.time", Value: "2024-05-01T12:00:00Z"},
+					{Key: "vcs.modified", Value: "true"},
+				},
+			},
+			"prog v1.2.3 (revision 0123456789ab, 2024-05-01T12:00:00Z, modified)",
+		},
+		{
+			&debug.BuildInfo{
+				Settings: []debug.BuildSetting{
+					{Key: "vcs.revision", Value: "abc"},
+					{Key: "vcs.modified", Value: "false"},
+				},
+			},
+			"prog (revision abc)",
+		},
+	}
+	for _, row := range table {
+		info := row.info
+		readBuildInfo = func() (*debug.BuildInfo, bool) { return info, info != nil }
+		if got := Version("prog"); got != row.want {
+			t.Errorf("Version(), got %q, want %q", got, row.want)
+		}
+	}
+}
+
+func TestVersionOption(t *testing.T) {
+	var buf bytes.Buffer
+	status := -1
+	oldStdout, oldExit, oldInfo := stdout, exit, readBuildInfo
+	stdout = &buf
+	exit = func(code int) { status = code }
+	readBuildInfo = func() (*debug.BuildInfo, bool) {
+		return &debug.BuildInfo{Main: debug.Module{Version: "v1.0.0"}}, true
+	}
+	defer func() {
+		stdout, exit, readBuildInfo = oldStdout, oldExit, oldInfo
+	}()
+
+	options := []Option{VersionOption("prog")}
+	if _, err := Run(options, []string{"", "--version"}); err != nil {
+		t.Fatalf("Run(), got %v", err)
+	}
+	if buf.String() != "prog v1.0.0\n" || status != 0 {
+		t.Errorf("--version, got %q status %d", buf.String(), status)
+	}
+}
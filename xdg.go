@@ -0,0 +1,80 @@
+// This is free and unencumbered software released into the public domain.
+
+package optparse
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// Hook for testing ConfigPaths.
+var goos = runtime.GOOS
+
+// ConfigPaths returns candidate paths of the named config file, such as
+// "config.toml", for the application, most preferred first, following
+// the XDG Base Directory Specification:
+//
+//	$XDG_CONFIG_HOME/app/name (default ~/.config/app/name)
+//	$XDG_CONFIG_DIRS/app/name (default /etc/xdg/app/name)
+//
+// On macOS, ~/Library/Application Support and /Library/Application
+// Support follow the XDG user and system directories, respectively. On
+// Windows, the candidates are under %APPDATA% and %ProgramData%.
+// Directories that cannot be determined are omitted. The paths need not
+// exist; see FindConfigFile.
+func ConfigPaths(app, name string) []string {
+	var dirs []string
+	home := os.Getenv("HOME")
+	switch goos {
+	case "windows":
+		dirs = append(dirs, os.Getenv("APPDATA"), os.Getenv("ProgramData"))
+	default:
+		user := os.Getenv("XDG_CONFIG_HOME")
+		switch {
+		case filepath.IsAbs(user):
+		case home != "":
+			user = filepath.Join(home, ".config")
+		default:
+			user = ""
+		}
+		dirs = append(dirs, user)
+		if goos == "darwin" && home != "" {
+			dirs = append(dirs, filepath.Join(home, "Library", "Application Support"))
+		}
+
+		var system []string
+		for _, dir := range strings.Split(os.Getenv("XDG_CONFIG_DIRS"), ":") {
+			if filepath.IsAbs(dir) {
+				system = append(system, dir)
+			}
+		}
+		if system == nil {
+			system = []string{"/etc/xdg"}
+		}
+		dirs = append(dirs, system...)
+		if goos == "darwin" {
+			dirs = append(dirs, "/Library/Application Support")
+		}
+	}
+
+	var paths []string
+	for _, dir := range dirs {
+		if dir != "" {
+			paths = append(paths, filepath.Join(dir, app, name))
+		}
+	}
+	return paths
+}
+
+// FindConfigFile returns the first of ConfigPaths that exists, ready for
+// ParseConfigFile, or an empty string if none exist.
+func FindConfigFile(app, name string) string {
+	for _, path := range ConfigPaths(app, name) {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
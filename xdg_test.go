@@ -0,0 +1,82 @@
+package optparse
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestConfigPaths(t *testing.T) {
+	defer func(s string) { goos = s }(goos)
+	tests := []struct {
+		goos string
+		env  map[string]string
+		want []string
+	}{
+		{
+			"linux",
+			map[string]string{"HOME": "/home/u"},
+			[]string{"/home/u/.config/app/c.toml", "/etc/xdg/app/c.toml"},
+		},
+		{
+			"linux",
+			map[string]string{
+				"HOME":            "/home/u",
+				"XDG_CONFIG_HOME": "/cfg",
+				"XDG_CONFIG_DIRS": "/a:relative:/b",
+			},
+			[]string{"/cfg/app/c.toml", "/a/app/c.toml", "/b/app/c.toml"},
+		},
+		{
+			"linux",
+			map[string]string{"XDG_CONFIG_HOME": "relative"},
+			[]string{"/etc/xdg/app/c.toml"},
+		},
+		{
+			"darwin",
+			map[string]string{"HOME": "/Users/u"},
+			[]string{
+				"/Users/u/.config/app/c.toml",
+				"/Users/u/Library/Application Support/app/c.toml",
+				"/etc/xdg/app/c.toml",
+				"/Library/Application Support/app/c.toml",
+			},
+		},
+		{
+			"windows",
+			map[string]string{"APPDATA": "/appdata", "ProgramData": "/programdata"},
+			[]string{"/appdata/app/c.toml", "/programdata/app/c.toml"},
+		},
+	}
+	for _, test := range tests {
+		for _, name := range []string{"HOME", "XDG_CONFIG_HOME", "XDG_CONFIG_DIRS", "APPDATA", "ProgramData"} {
+			t.Setenv(name, test.env[name])
+		}
+		goos = test.goos
+		if got := ConfigPaths("app", "c.toml"); !equal(got, test.want) {
+			t.Errorf("ConfigPaths() on %s %v, got %q, want %q", test.goos, test.env, got, test.want)
+		}
+	}
+}
+
+func TestFindConfigFile(t *testing.T) {
+	defer func(s string) { goos = s }(goos)
+	goos = "linux"
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(dir, "home"))
+	t.Setenv("XDG_CONFIG_DIRS", filepath.Join(dir, "system"))
+
+	if got := FindConfigFile("app", "c.ini"); got != "" {
+		t.Errorf("FindConfigFile(), got %q, want none", got)
+	}
+	path := filepath.Join(dir, "system", "app", "c.ini")
+	if err := os.MkdirAll(filepath.Dir(path), 0o777); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, nil, 0o666); err != nil {
+		t.Fatal(err)
+	}
+	if got := FindConfigFile("app", "c.ini"); got != path {
+		t.Errorf("FindConfigFile(), got %q, want %q", got, path)
+	}
+}
@@ -0,0 +1,267 @@
+// This is free and unencumbered software released into the public domain.
+
+package optparse
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ParseYAML is like ParseINI but reads a YAML config file, implementing
+// the subset of YAML suited to configuration without any dependencies.
+// A key within nested mappings names the option by the dotted path of
+// keys, so delay under net sets --net.delay. Sequences, in block or flow
+// style, give repeated options, and booleans set options without an
+// argument, as does a key with no value. Block scalars, flow mappings,
+// and mappings within sequences are not supported.
+func ParseYAML(r io.Reader, options []Option) (Results, error) {
+	return parseConfig(readYAML, r, options)
+}
+
+// MergeYAML is like MergeTOML but reads a YAML config file, with nested
+// mappings in place of tables.
+func (inv *Invocation) MergeYAML(r io.Reader) error {
+	entries, err := readYAML(r)
+	if err != nil {
+		return err
+	}
+	return inv.merge(entries)
+}
+
+// yamlNode is an open mapping key in a YAML file.
+type yamlNode struct {
+	key    string
+	indent int
+	line   int
+	child  int  // indentation of nested keys, or -1
+	used   bool // has a value
+}
+
+// readYAML reads the entries of a YAML file up to the first error.
+func readYAML(r io.Reader) ([]entry, error) {
+	var entries []entry
+	stack := []yamlNode{{indent: -1, child: -1}}
+	path := func(key string) []string {
+		var keys []string
+		for _, node := range stack[1:] {
+			keys = append(keys, node.key)
+		}
+		if key != "" {
+			keys = append(keys, key)
+		}
+		return keys
+	}
+	pop := func() {
+		if top := stack[len(stack)-1]; !top.used {
+			entries = append(entries, entry{path(""), "", false, top.line})
+		}
+		stack = stack[:len(stack)-1]
+	}
+
+	s := bufio.NewScanner(r)
+scan:
+	for line := 1; s.Scan(); line++ {
+		fail := func(err error) ([]entry, error) {
+			return entries, ConfigError{Line: line, Err: err}
+		}
+		raw := s.Text()
+		text := strings.TrimLeft(raw, " ")
+		indent := len(raw) - len(text)
+		switch text = strings.TrimSpace(text); {
+		case comment(text) || text == "---" && indent == 0:
+			continue
+		case text == "..." && indent == 0:
+			break scan
+		case raw[indent] == '\t':
+			return fail(errors.New("tabs are not allowed for indentation"))
+		}
+
+		if text == "-" || strings.HasPrefix(text, "- ") {
+			for stack[len(stack)-1].indent > indent {
+				pop()
+			}
+			top := &stack[len(stack)-1]
+			if len(stack) == 1 || top.child != -1 {
+				return fail(errors.New("unexpected sequence"))
+			}
+			top.used = true
+			item := strings.TrimSpace(text[1:])
+			if _, rest, err := yamlKey(item); err == nil && rest != "" {
+				return fail(errors.New("mappings within sequences are not supported"))
+			}
+			value, rest, err := yamlScalar(item, false)
+			if err != nil {
+				return fail(err)
+			}
+			if !comment(rest) {
+				return fail(fmt.Errorf("unexpected %q", rest))
+			}
+			entries = append(entries, entry{path(""), value, true, line})
+			continue
+		}
+
+		for stack[len(stack)-1].indent >= indent {
+			pop()
+		}
+		top := &stack[len(stack)-1]
+		switch {
+		case top.child == -1:
+			top.child = indent
+		case top.child != indent:
+			return fail(errors.New("inconsistent indentation"))
+		}
+		top.used = true
+
+		key, rest, err := yamlKey(text)
+		switch {
+		case err != nil:
+			return fail(err)
+		case comment(rest):
+			stack = append(stack, yamlNode{key: key, indent: indent, line: line, child: -1})
+			continue
+		case yamlNull(rest):
+			entries = append(entries, entry{path(key), "", false, line})
+			continue
+		}
+		values, rest, err := yamlValue(rest)
+		if err != nil {
+			return fail(err)
+		}
+		if !comment(rest) {
+			return fail(fmt.Errorf("unexpected %q", rest))
+		}
+		for _, value := range values {
+			entries = append(entries, entry{path(key), value, true, line})
+		}
+	}
+	for len(stack) > 1 {
+		pop()
+	}
+	return entries, s.Err()
+}
+
+// yamlKey parses the key of a mapping entry, returning the remaining
+// text with surrounding space removed.
+func yamlKey(s string) (string, string, error) {
+	var key, rest string
+	if strings.HasPrefix(s, `"`) || strings.HasPrefix(s, "'") {
+		var err error
+		key, rest, err = yamlScalar(s, false)
+		if err != nil {
+			return "", s, err
+		}
+	} else {
+		n := strings.Index(s, ": ")
+		switch {
+		case n >= 0:
+		case strings.HasSuffix(s, ":"):
+			n = len(s) - 1
+		default:
+			return "", s, fmt.Errorf("expected key: value, got %q", s)
+		}
+		key, rest = strings.TrimSpace(s[:n]), s[n:]
+	}
+	if rest != ":" && !strings.HasPrefix(rest, ": ") {
+		return "", s, fmt.Errorf("expected key: value, got %q", s)
+	}
+	return key, strings.TrimSpace(rest[1:]), nil
+}
+
+// yamlNull reports whether the plain scalar s, possibly followed by a
+// comment, is null.
+func yamlNull(s string) bool {
+	value, _, _ := strings.Cut(s, " #")
+	switch strings.TrimSpace(value) {
+	case "~", "null", "Null", "NULL":
+		return true
+	}
+	return false
+}
+
+// yamlValue parses the value of a mapping entry, returning each element
+// if it is a flow sequence, and the remaining text.
+func yamlValue(s string) ([]string, string, error) {
+	switch s[0] {
+	case '{':
+		return nil, s, errors.New("flow mappings are not supported")
+	case '|', '>':
+		return nil, s, errors.New("block scalars are not supported")
+	case '[':
+	default:
+		value, rest, err := yamlScalar(s, false)
+		return []string{value}, rest, err
+	}
+
+	var values []string
+	s = strings.TrimSpace(s[1:])
+	for !strings.HasPrefix(s, "]") {
+		if s == "" || s[0] == '[' || s[0] == '{' {
+			return nil, s, errors.New("invalid flow sequence")
+		}
+		value, rest, err := yamlScalar(s, true)
+		if err != nil {
+			return nil, rest, err
+		}
+		values = append(values, value)
+		switch {
+		case strings.HasPrefix(rest, ","):
+			s = strings.TrimSpace(rest[1:])
+		case strings.HasPrefix(rest, "]"):
+			s = rest
+		default:
+			return nil, rest, errors.New("invalid flow sequence")
+		}
+	}
+	return values, strings.TrimSpace(s[1:]), nil
+}
+
+// yamlScalar parses a quoted or plain scalar at the start of s,
+// returning the remaining text with surrounding space removed. Within
+// a flow sequence, plain scalars also end at a comma or bracket.
+func yamlScalar(s string, flow bool) (string, string, error) {
+	switch {
+	case strings.HasPrefix(s, `"`):
+		for i := 1; i < len(s); i++ {
+			switch s[i] {
+			case '\\':
+				i++
+			case '"':
+				value, err := strconv.Unquote(s[:i+1])
+				if err != nil {
+					return "", s, fmt.Errorf("invalid string %s", s[:i+1])
+				}
+				return value, strings.TrimSpace(s[i+1:]), nil
+			}
+		}
+		return "", s, fmt.Errorf("unterminated string %s", s)
+	case strings.HasPrefix(s, "'"):
+		var b strings.Builder
+		for i := 1; i < len(s); i++ {
+			switch {
+			case s[i] != '\'':
+				b.WriteByte(s[i])
+			case i+1 < len(s) && s[i+1] == '\'':
+				b.WriteByte('\'')
+				i++
+			default:
+				return b.String(), strings.TrimSpace(s[i+1:]), nil
+			}
+		}
+		return "", s, fmt.Errorf("unterminated string %s", s)
+	}
+
+	n := strings.Index(s, " #")
+	if flow {
+		if m := strings.IndexAny(s, ",]"); m >= 0 && (n < 0 || m < n) {
+			n = m
+		}
+	}
+	if n < 0 {
+		n = len(s)
+	}
+	return strings.TrimSpace(s[:n]), strings.TrimSpace(s[n:]), nil
+}
@@ -0,0 +1,120 @@
+package optparse
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseYAML(t *testing.T) {
+	options := []Option{
+		{Long: "color", Kind: KindOptional},
+		{Long: "verbose", Short: 'v', Kind: KindNone},
+		{Long: "quiet", Kind: KindNone},
+		{Long: "jobs", Kind: KindRequired, Type: TypeInt},
+		{Long: "include", Short: 'I', Kind: KindRequired},
+		{Long: "define", Short: 'D', Kind: KindRequired},
+		{Long: "net.delay", Kind: KindRequired},
+		{Long: "net.proxy", Kind: KindRequired},
+	}
+	input := `---
+# comment
+color: "al\"ways" # trailing
+verbose:
+quiet: no
+jobs: 4
+include: [a, 'b''s', "c"]
+define:
+- x=1
+-   y = 2  # comment
+net:
+    delay: 10
+    proxy: http://example.com:8080/
+...
+bogus: 1
+`
+	results, err := ParseYAML(strings.NewReader(input), options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct {
+		long, optarg string
+		line         int
+	}{
+		{"color", `al"ways`, 3},
+		{"verbose", "", 4},
+		{"jobs", "4", 6},
+		{"include", "a", 7},
+		{"include", "b's", 7},
+		{"include", "c", 7},
+		{"define", "x=1", 9},
+		{"define", "y = 2", 10},
+		{"net.delay", "10", 12},
+		{"net.proxy", "http://example.com:8080/", 13},
+	}
+	if len(results) != len(want) {
+		t.Fatalf("ParseYAML(), got %d results %v, want %d", len(results), results, len(want))
+	}
+	for i, w := range want {
+		r := results[i]
+		if r.Long != w.long || r.Optarg != w.optarg || r.Index != w.line {
+			t.Errorf("ParseYAML()[%d], got %q %q %d, want %q %q %d",
+				i, r.Long, r.Optarg, r.Index, w.long, w.optarg, w.line)
+		}
+	}
+
+	errs := []struct {
+		input string
+		msg   string
+	}{
+		{"bogus: 1", "line 1: invalid option: --bogus"},
+		{"\njobs", `line 2: expected key: value, got "jobs"`},
+		{"jobs: 1 # x\n  jobs: 2", "line 2: inconsistent indentation"},
+		{"net:\n  delay: 1\n   proxy: 2", "line 3: inconsistent indentation"},
+		{"- a", "line 1: unexpected sequence"},
+		{"include:\n- a: b", "line 2: mappings within sequences are not supported"},
+		{"include: {a: b}", "line 1: flow mappings are not supported"},
+		{"include: |", "line 1: block scalars are not supported"},
+		{"include: [a, [b]]", "line 1: invalid flow sequence"},
+		{"color: 'x", "line 1: unterminated string 'x"},
+		{"color: 'x' y", `line 1: unexpected "y"`},
+		{"\tcolor: x", "line 1: tabs are not allowed for indentation"},
+		{"jobs: ~", "line 1: option requires an argument: --jobs"},
+	}
+	for _, test := range errs {
+		_, err := ParseYAML(strings.NewReader(test.input), options)
+		var cerr ConfigError
+		if !errors.As(err, &cerr) || err.Error() != test.msg {
+			t.Errorf("ParseYAML(%q), got %v, want %q", test.input, err, test.msg)
+		}
+	}
+}
+
+func TestMergeYAML(t *testing.T) {
+	add := &Command{
+		Name:    "add",
+		Options: []Option{{Long: "fetch", Short: 'f', Kind: KindNone}},
+	}
+	root := &Command{
+		Name:     "git",
+		Options:  []Option{{Long: "color", Kind: KindRequired, Persistent: true}},
+		Commands: []*Command{{Name: "remote", Commands: []*Command{add, {Name: "remove"}}}},
+	}
+	inv, err := root.Parse([]string{"git", "remote", "add"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	input := `remote:
+  add:
+    fetch: true
+    color: never
+  remove:
+    bogus: 1
+`
+	if err := inv.MergeYAML(strings.NewReader(input)); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := inv.Results[0].Get("color"); got != "never" || !inv.Results[2].Has("fetch") {
+		t.Errorf("MergeYAML(), got %v", inv.Results)
+	}
+}